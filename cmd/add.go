@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
 	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/preset"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +22,16 @@ var addCmd = &cobra.Command{
 	Run:     runAdd,
 }
 
+var (
+	addPreset string
+	addAlias  string
+)
+
+func init() {
+	addCmd.Flags().StringVarP(&addPreset, "preset", "p", "", "Pre-fill the provider from a preset (see 'ccs presets list')")
+	addCmd.Flags().StringVar(&addAlias, "alias", "", "Alias for a provider added from a preset (default: preset name)")
+}
+
 func runAdd(cmd *cobra.Command, args []string) {
 	cfg, err := config.Load()
 	if err != nil {
@@ -23,6 +39,11 @@ func runAdd(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if addPreset != "" {
+		runAddPreset(cfg)
+		return
+	}
+
 	var provider config.Provider
 
 	questions := []*survey.Question{
@@ -34,7 +55,7 @@ func runAdd(cmd *cobra.Command, args []string) {
 		{
 			Name:     "alias",
 			Prompt:   &survey.Input{Message: "Alias:"},
-			Validate: survey.Required,
+			Validate: validateNewAlias,
 		},
 		{
 			Name:     "baseurl",
@@ -46,10 +67,6 @@ func runAdd(cmd *cobra.Command, args []string) {
 			Prompt:   &survey.Password{Message: "API Key:"},
 			Validate: survey.Required,
 		},
-		{
-			Name:   "model",
-			Prompt: &survey.Input{Message: "Model (empty for Claude default):"},
-		},
 	}
 
 	answers := struct {
@@ -57,7 +74,6 @@ func runAdd(cmd *cobra.Command, args []string) {
 		Alias   string
 		BaseURL string
 		APIKey  string
-		Model   string
 	}{}
 
 	if err := survey.Ask(questions, &answers); err != nil {
@@ -68,21 +84,21 @@ func runAdd(cmd *cobra.Command, args []string) {
 	provider.Alias = answers.Alias
 	provider.BaseURL = answers.BaseURL
 	provider.APIKey = answers.APIKey
-	provider.Model = answers.Model
+	provider.APIFormat = askAPIFormat("")
+
+	ids := discoverModelIDs(&provider)
+	provider.Model = askModel("Model (empty for Claude default):", "", "(Claude default)", ids)
 
 	// Optional fields
-	var smallModel, sonnetModel, opusModel, haikuModel, timeoutStr string
+	var timeoutStr, envStr, settingsStr string
 
-	survey.AskOne(&survey.Input{Message: "Small model (empty=main):"}, &smallModel)
-	survey.AskOne(&survey.Input{Message: "Sonnet model (empty=main):"}, &sonnetModel)
-	survey.AskOne(&survey.Input{Message: "Opus model (empty=main):"}, &opusModel)
-	survey.AskOne(&survey.Input{Message: "Haiku model (empty=main):"}, &haikuModel)
+	provider.SmallModel = askModel("Small model (empty=main):", "", "(same as main)", ids)
+	provider.SonnetModel = askModel("Sonnet model (empty=main):", "", "(same as main)", ids)
+	provider.OpusModel = askModel("Opus model (empty=main):", "", "(same as main)", ids)
+	provider.HaikuModel = askModel("Haiku model (empty=main):", "", "(same as main)", ids)
 	survey.AskOne(&survey.Input{Message: "Timeout ms:", Default: "300000"}, &timeoutStr)
-
-	provider.SmallModel = smallModel
-	provider.SonnetModel = sonnetModel
-	provider.OpusModel = opusModel
-	provider.HaikuModel = haikuModel
+	survey.AskOne(&survey.Multiline{Message: "Extra env vars (KEY=VALUE per line):"}, &envStr,
+		survey.WithValidator(validateEnvLines))
 
 	if timeout, err := strconv.Atoi(timeoutStr); err == nil {
 		provider.Timeout = timeout
@@ -90,6 +106,82 @@ func runAdd(cmd *cobra.Command, args []string) {
 		provider.Timeout = 300000
 	}
 
+	provider.Env, _ = parseEnvLines(envStr)
+
+	survey.AskOne(&survey.Multiline{Message: "Settings overlay (JSON object, empty=none):"}, &settingsStr,
+		survey.WithValidator(validateSettingsOverlay))
+	provider.Settings, _ = parseSettingsOverlay(settingsStr)
+
+	disableTraffic := true
+	survey.AskOne(&survey.Confirm{Message: "Disable nonessential traffic?", Default: true}, &disableTraffic)
+	provider.DisableNonessentialTraffic = &disableTraffic
+
+	saveNewProvider(cfg, provider)
+}
+
+// validateNewAlias is the survey validator for a new provider's alias
+func validateNewAlias(ans interface{}) error {
+	if err := config.ValidateAlias(ans.(string)); err != nil {
+		return errors.New("use letters, digits, '.', '_' and '-'")
+	}
+	return nil
+}
+
+// askAPIFormat asks which API a provider speaks, returning "" for the
+// default Anthropic format
+func askAPIFormat(current string) string {
+	format := config.FormatAnthropic
+	if current != "" {
+		format = current
+	}
+	prompt := &survey.Select{
+		Message: "API format:",
+		Options: []string{config.FormatAnthropic, config.FormatOpenAI},
+		Default: format,
+	}
+	survey.AskOne(prompt, &format)
+	if format == config.FormatAnthropic {
+		return ""
+	}
+	return format
+}
+
+// runAddPreset adds a provider pre-filled from a preset, asking only for the API key
+func runAddPreset(cfg *config.Config) {
+	p, err := preset.Get(addPreset)
+	if err != nil {
+		if err == preset.ErrPresetNotFound {
+			color.Red("Preset '%s' not found", addPreset)
+		} else {
+			color.Red("Failed to load presets: %v", err)
+		}
+		return
+	}
+
+	provider := p.Provider()
+	if addAlias != "" {
+		if err := config.ValidateAlias(addAlias); err != nil {
+			color.Red("Invalid alias '%s', use letters, digits, '.', '_' and '-'", addAlias)
+			return
+		}
+		provider.Alias = addAlias
+	}
+
+	if _, err := cfg.GetProvider(provider.Alias); err == nil {
+		color.Red("Provider '%s' already exists, choose another with --alias", provider.Alias)
+		return
+	}
+
+	prompt := &survey.Password{Message: fmt.Sprintf("API Key for %s:", provider.Name)}
+	if err := survey.AskOne(prompt, &provider.APIKey, survey.WithValidator(survey.Required)); err != nil {
+		return
+	}
+
+	saveNewProvider(cfg, provider)
+}
+
+// saveNewProvider adds a provider to the config and saves it
+func saveNewProvider(cfg *config.Config, provider config.Provider) {
 	if err := cfg.AddProvider(provider); err != nil {
 		if err == config.ErrProviderExists {
 			color.Red("Provider '%s' already exists", provider.Alias)
@@ -106,3 +198,83 @@ func runAdd(cmd *cobra.Command, args []string) {
 
 	color.Green("Provider '%s' added", provider.Name)
 }
+
+// parseEnvLines parses KEY=VALUE lines into an env map, skipping blank lines
+func parseEnvLines(text string) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid env line %q, expected KEY=VALUE", line)
+		}
+		env[key] = value
+	}
+	if len(env) == 0 {
+		return nil, nil
+	}
+	return env, nil
+}
+
+// formatEnvLines formats an env map as sorted KEY=VALUE lines
+func formatEnvLines(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + env[key]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validateEnvLines is a survey validator for KEY=VALUE lines
+func validateEnvLines(ans interface{}) error {
+	text, _ := ans.(string)
+	_, err := parseEnvLines(text)
+	return err
+}
+
+// parseSettingsOverlay parses a JSON object used as a settings.json overlay
+func parseSettingsOverlay(text string) (map[string]interface{}, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var overlay map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &overlay); err != nil {
+		return nil, fmt.Errorf("invalid settings overlay: %v", err)
+	}
+	if _, ok := overlay["env"]; ok {
+		return nil, fmt.Errorf("use env vars instead of an \"env\" key in the settings overlay")
+	}
+	if len(overlay) == 0 {
+		return nil, nil
+	}
+	return overlay, nil
+}
+
+// formatSettingsOverlay formats a settings overlay as indented JSON
+func formatSettingsOverlay(overlay map[string]interface{}) string {
+	if len(overlay) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(overlay, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// validateSettingsOverlay is a survey validator for a settings overlay
+func validateSettingsOverlay(ans interface{}) error {
+	text, _ := ans.(string)
+	_, err := parseSettingsOverlay(text)
+	return err
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/health"
+	"github.com/katz/ccs/internal/probe"
+	"github.com/katz/ccs/internal/usage"
+)
+
+// bestHistory is how much health history smooths a --best probe
+const bestHistory = 24 * time.Hour
+
+// pickBest probes the candidate providers (all providers if aliases is
+// empty) and returns the alias of the best one, printing the ranking and
+// why it won. It returns "" if no candidate is reachable
+func pickBest(cfg *config.Config, aliases []string) string {
+	providers := cfg.Providers
+	if len(aliases) > 0 {
+		providers = nil
+		for _, alias := range aliases {
+			p, err := cfg.GetProvider(resolveAlias(cfg, alias))
+			if err != nil {
+				color.Red("Provider '%s' not found", alias)
+				return ""
+			}
+			providers = append(providers, *p)
+		}
+	}
+	providers = withinHardBudget(cfg, providers)
+	if len(providers) == 0 {
+		color.Yellow("No candidate providers")
+		return ""
+	}
+
+	fmt.Printf("Probing %d providers...\n", len(providers))
+	// The timeout applies to each request, since --full probes a provider's
+	// models one after another
+	prober := &probe.Prober{Timeout: useBestTimeout, PingOnly: !useBestFull}
+	results := prober.ProbeAll(context.Background(), providers)
+
+	// History is loaded before the new samples are added, so a candidate's
+	// stats only cover earlier probes
+	aliases = make([]string, len(providers))
+	for i, p := range providers {
+		aliases[i] = p.Alias
+	}
+	var history []health.Sample
+	store, err := health.Open()
+	if err == nil {
+		history, _ = store.Load(time.Now().Add(-bestHistory))
+	}
+	mode := health.ProbeMode(useBestFull)
+	stats := health.Summarize(history, aliases, mode)
+
+	candidates := make([]health.Candidate, len(results))
+	samples := make([]health.Sample, len(results))
+	for i, r := range results {
+		samples[i] = health.FromResult(r, mode)
+		candidates[i] = health.Candidate{Sample: samples[i], Stats: stats[i]}
+	}
+	if store != nil {
+		store.Append(samples)
+	}
+
+	health.Rank(candidates)
+	for _, c := range candidates {
+		printCandidate(c)
+	}
+
+	best := candidates[0]
+	if !best.Sample.OK {
+		color.Red("No provider is reachable")
+		return ""
+	}
+
+	reason := fmt.Sprintf("responded in %dms", best.Sample.LatencyMS)
+	if best.Stats.Samples > 0 {
+		reason += fmt.Sprintf(", %.0f%% errors over %d earlier probes in the last 24h", best.Stats.ErrorRate*100, best.Stats.Samples)
+	}
+	if len(candidates) > 1 && candidates[1].Sample.OK {
+		reason += fmt.Sprintf(", score %.0f vs %.0f for '%s'", best.Score, candidates[1].Score, candidates[1].Sample.Provider)
+	}
+	fmt.Printf("Best: '%s' %s\n", best.Sample.Provider, reason)
+	return best.Sample.Provider
+}
+
+// printCandidate prints one ranked candidate
+func printCandidate(c health.Candidate) {
+	if !c.Sample.OK {
+		color.Red("  %-16s failed: %s", c.Sample.Provider, truncate(c.Sample.Error, 60))
+		return
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("%dms", c.Sample.LatencyMS))
+	if c.Stats.Samples > 0 {
+		parts = append(parts, fmt.Sprintf("p50 %s", formatLatency(c.Stats.P50MS)), fmt.Sprintf("%.0f%% errors", c.Stats.ErrorRate*100))
+	}
+	parts = append(parts, fmt.Sprintf("score %.0f", c.Score))
+	fmt.Printf("  %-16s %s\n", c.Sample.Provider, strings.Join(parts, ", "))
+}
+
+// withinHardBudget drops providers that exceeded a hard budget, since the
+// proxy would refuse their requests
+func withinHardBudget(cfg *config.Config, providers []config.Provider) []config.Provider {
+	var records []usage.Record
+	loaded := false
+
+	var kept []config.Provider
+	for _, p := range providers {
+		if p.Budget != nil && p.Budget.Hard {
+			if !loaded {
+				records, _ = loadBudgetRecords()
+				loaded = true
+			}
+			if usage.AnyExceeded(usage.CheckBudget(p.Alias, p.Budget, records, cfg.Prices, time.Now())) {
+				color.Yellow("  %-16s skipped: over its hard budget", p.Alias)
+				continue
+			}
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Show remaining budget per provider",
+	Run:   runBudget,
+}
+
+var budgetSetCmd = &cobra.Command{
+	Use:   "set <alias>",
+	Short: "Set a provider's budget limits (0 removes a limit)",
+	Long: `Set a provider's budget limits. Only the limits given are changed, the
+others are kept; 0 removes a limit and --hard=false makes the budget soft.
+--clear removes the whole budget.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runBudgetSet,
+}
+
+var (
+	budgetLimits config.Budget
+	budgetClear  bool
+)
+
+func init() {
+	budgetSetCmd.Flags().Int64Var(&budgetLimits.DailyTokens, "daily-tokens", 0, "Daily token limit")
+	budgetSetCmd.Flags().Int64Var(&budgetLimits.MonthlyTokens, "monthly-tokens", 0, "Monthly token limit")
+	budgetSetCmd.Flags().Float64Var(&budgetLimits.DailyCost, "daily-cost", 0, "Daily cost limit in USD")
+	budgetSetCmd.Flags().Float64Var(&budgetLimits.MonthlyCost, "monthly-cost", 0, "Monthly cost limit in USD")
+	budgetSetCmd.Flags().BoolVar(&budgetLimits.Hard, "hard", false, "Make the proxy refuse requests once exceeded")
+	budgetSetCmd.Flags().BoolVar(&budgetClear, "clear", false, "Remove the provider's budget")
+	budgetCmd.AddCommand(budgetSetCmd)
+}
+
+func runBudget(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	records, err := loadBudgetRecords()
+	if err != nil {
+		color.Red("Failed to load usage: %v", err)
+		return
+	}
+
+	now := time.Now()
+	shown := false
+	for _, p := range cfg.Providers {
+		if p.Budget == nil {
+			continue
+		}
+		shown = true
+
+		mode := "soft"
+		if p.Budget.Hard {
+			mode = "hard"
+		}
+		fmt.Printf("%s (%s) [%s]\n", p.Name, p.Alias, mode)
+
+		for _, bs := range usage.CheckBudget(p.Alias, p.Budget, records, cfg.Prices, now) {
+			line := fmt.Sprintf("  %-7s %-6s %s of %s used, %s left",
+				bs.Period, bs.Kind, formatBudget(bs.Kind, bs.Used), formatBudget(bs.Kind, bs.Limit), formatBudget(bs.Kind, bs.Remaining()))
+			if bs.Exceeded {
+				color.Red("%s", line)
+			} else {
+				fmt.Println(line)
+			}
+		}
+	}
+
+	if !shown {
+		color.Yellow("No budgets configured. Use 'ccs budget set <alias>' to add one.")
+	}
+}
+
+func runBudgetSet(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	p, err := cfg.GetProvider(resolveAlias(cfg, args[0]))
+	if err != nil {
+		color.Red("Provider '%s' not found", args[0])
+		return
+	}
+
+	flags := cmd.Flags()
+	if budgetClear {
+		if flags.NFlag() > 1 {
+			color.Red("--clear cannot be combined with other limits")
+			return
+		}
+		p.Budget = nil
+	} else {
+		if flags.NFlag() == 0 {
+			color.Red("Nothing to set, give a limit or --clear")
+			return
+		}
+
+		// Limits not given are kept
+		var b config.Budget
+		if p.Budget != nil {
+			b = *p.Budget
+		}
+		if flags.Changed("daily-tokens") {
+			b.DailyTokens = budgetLimits.DailyTokens
+		}
+		if flags.Changed("monthly-tokens") {
+			b.MonthlyTokens = budgetLimits.MonthlyTokens
+		}
+		if flags.Changed("daily-cost") {
+			b.DailyCost = budgetLimits.DailyCost
+		}
+		if flags.Changed("monthly-cost") {
+			b.MonthlyCost = budgetLimits.MonthlyCost
+		}
+		if flags.Changed("hard") {
+			b.Hard = budgetLimits.Hard
+		}
+
+		if b == (config.Budget{Hard: b.Hard}) {
+			p.Budget = nil
+		} else {
+			p.Budget = &b
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+
+	if p.Budget == nil {
+		color.Green("Budget removed from '%s'", p.Name)
+	} else {
+		color.Green("Budget set for '%s'", p.Name)
+	}
+}
+
+// warnBudget warns if a provider has exceeded any of its budget limits
+func warnBudget(cfg *config.Config, p *config.Provider) {
+	if p.Budget == nil {
+		return
+	}
+
+	records, err := loadBudgetRecords()
+	if err != nil {
+		return
+	}
+
+	for _, bs := range usage.CheckBudget(p.Alias, p.Budget, records, cfg.Prices, time.Now()) {
+		if bs.Exceeded {
+			color.Yellow("Warning: '%s' exceeded its %s %s budget (%s of %s)",
+				p.Alias, bs.Period, bs.Kind, formatBudget(bs.Kind, bs.Used), formatBudget(bs.Kind, bs.Limit))
+		}
+	}
+}
+
+// loadBudgetRecords loads the usage records any budget period can cover
+func loadBudgetRecords() ([]usage.Record, error) {
+	store, err := usage.Open()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load(usage.BudgetStart(time.Now()))
+}
+
+func formatBudget(kind string, value float64) string {
+	if kind == usage.KindCost {
+		return fmt.Sprintf("$%.2f", value)
+	}
+	return fmt.Sprintf("%.0f tokens", value)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <src> <new-alias>",
+	Short: "Copy a provider under a new alias, with overrides",
+	Long: `Copy a provider under a new alias. --set field=value overrides a field of
+the copy and may be repeated. Fields use their config.json names:
+
+  name, base_url, api_key, api_format, model, small_model, sonnet_model,
+  opus_model, haiku_model, timeout_ms, tags, disable_nonessential_traffic,
+  env.<KEY>
+
+Setting model also moves the model roles that used the old main model.
+tags takes a comma-separated list, and env.<KEY>= with an empty value
+removes the variable.`,
+	Example: `  ccs clone or or-opus --set model=anthropic/claude-opus-4 --set tags=client-a`,
+	Args:    cobra.ExactArgs(2),
+	Run:     runClone,
+}
+
+var cloneSets []string
+
+func init() {
+	cloneCmd.Flags().StringArrayVar(&cloneSets, "set", nil, "Override a field, field=value (repeatable)")
+}
+
+func runClone(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	src, err := cfg.GetProvider(resolveAlias(cfg, args[0]))
+	if err != nil {
+		color.Red("Provider '%s' not found", args[0])
+		return
+	}
+
+	alias := args[1]
+	if err := config.ValidateAlias(alias); err != nil {
+		color.Red("Invalid alias '%s', use letters, digits, '.', '_' and '-'", alias)
+		return
+	}
+
+	clone := src.Clone()
+	clone.Alias = alias
+	clone.LastUsed = nil
+
+	sets, err := parseSets(cloneSets)
+	if err != nil {
+		color.Red("%v", err)
+		return
+	}
+	for _, s := range sets {
+		if err := clone.Set(s.field, s.value); err != nil {
+			color.Red("Invalid --set %s: %v", s.field, err)
+			return
+		}
+	}
+	if err := clone.Validate(); err != nil {
+		color.Red("%v", err)
+		return
+	}
+
+	if err := cfg.AddProvider(clone); err != nil {
+		if err == config.ErrProviderExists {
+			color.Red("Provider '%s' already exists", alias)
+		} else {
+			color.Red("Failed to add provider: %v", err)
+		}
+		return
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+
+	color.Green("Provider '%s' cloned from '%s'", alias, src.Alias)
+}
+
+// fieldSet is one --set override
+type fieldSet struct {
+	field string
+	value string
+}
+
+// parseSets parses field=value overrides. The main model is set first so
+// the roles following it do not overwrite an explicit role override
+func parseSets(exprs []string) ([]fieldSet, error) {
+	sets := make([]fieldSet, 0, len(exprs))
+	for _, expr := range exprs {
+		field, value, ok := strings.Cut(expr, "=")
+		field = strings.TrimSpace(field)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("invalid --set %q, expected field=value", expr)
+		}
+		if field == "alias" {
+			return nil, errors.New("the alias of the copy is the <new-alias> argument, not a --set field")
+		}
+		if !contains(config.Fields, field) && !strings.HasPrefix(field, "env.") {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s or env.<KEY>", field, strings.Join(cloneFields(), ", "))
+		}
+		sets = append(sets, fieldSet{field: field, value: value})
+	}
+	sort.SliceStable(sets, func(i, j int) bool {
+		return sets[i].field == "model" && sets[j].field != "model"
+	})
+	return sets, nil
+}
+
+// cloneFields are the fields --set accepts besides env.<KEY>
+func cloneFields() []string {
+	var fields []string
+	for _, f := range config.Fields {
+		if f != "alias" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
@@ -1,12 +1,11 @@
 package cmd
 
 import (
-	"fmt"
+	"errors"
 	"strconv"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
-	"github.com/katz/ccs/internal/claude"
 	"github.com/katz/ccs/internal/config"
 	"github.com/spf13/cobra"
 )
@@ -34,22 +33,14 @@ func runEdit(cmd *cobra.Command, args []string) {
 	if len(args) > 0 {
 		alias = args[0]
 	} else {
-		options := make([]string, len(cfg.Providers))
-		for i, p := range cfg.Providers {
-			options[i] = fmt.Sprintf("%s (%s)", p.Name, p.Alias)
-		}
-
-		var selected int
-		prompt := &survey.Select{
-			Message: "Select provider:",
-			Options: options,
-		}
-		if err := survey.AskOne(prompt, &selected); err != nil {
+		picked, ok := pickProvider(cfg, "edit")
+		if !ok {
 			return
 		}
-		alias = cfg.Providers[selected].Alias
+		alias = picked
 	}
 
+	alias = resolveAlias(cfg, alias)
 	provider, err := cfg.GetProvider(alias)
 	if err != nil {
 		color.Red("Provider '%s' not found", alias)
@@ -68,6 +59,10 @@ func runEdit(cmd *cobra.Command, args []string) {
 		"Opus model",
 		"Haiku model",
 		"Timeout",
+		"Env vars",
+		"Disable nonessential traffic",
+		"Settings overlay",
+		"API format",
 	}
 
 	var selectedField int
@@ -79,6 +74,7 @@ func runEdit(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	original := *provider
 	updated := *provider
 
 	if selectedField == 0 {
@@ -87,11 +83,18 @@ func runEdit(cmd *cobra.Command, args []string) {
 		editField(&updated, selectedField)
 	}
 
+	if err := updated.Validate(); err != nil {
+		color.Red("%v", err)
+		return
+	}
+
 	isCurrentProvider := (cfg.CurrentProvider == alias) || (cfg.CurrentProvider == updated.Alias)
 
 	if err := cfg.UpdateProvider(alias, updated); err != nil {
 		if err == config.ErrProviderExists {
 			color.Red("Provider '%s' already exists", updated.Alias)
+		} else if errors.Is(err, config.ErrInvalidAlias) {
+			color.Red("Invalid alias '%s', use letters, digits, '.', '_' and '-'", updated.Alias)
 		} else {
 			color.Red("Failed to update: %v", err)
 		}
@@ -102,9 +105,14 @@ func runEdit(cmd *cobra.Command, args []string) {
 		color.Red("Failed to save: %v", err)
 		return
 	}
+	if updated.Alias != alias {
+		if err := renameRecords(alias, updated.Alias); err != nil {
+			color.Yellow("Warning: Failed to update history: %v", err)
+		}
+	}
 
 	if isCurrentProvider {
-		if err := updateClaudeSettings(&updated); err != nil {
+		if err := updateClaudeSettings(cfg, &original, &updated); err != nil {
 			color.Yellow("Warning: Failed to update Claude settings: %v", err)
 		}
 	}
@@ -114,7 +122,7 @@ func runEdit(cmd *cobra.Command, args []string) {
 
 func editAllFields(p *config.Provider) {
 	survey.AskOne(&survey.Input{Message: "Name:", Default: p.Name}, &p.Name)
-	survey.AskOne(&survey.Input{Message: "Alias:", Default: p.Alias}, &p.Alias)
+	editAlias(p)
 	survey.AskOne(&survey.Input{Message: "Base URL:", Default: p.BaseURL}, &p.BaseURL)
 
 	var apiKey string
@@ -122,18 +130,19 @@ func editAllFields(p *config.Provider) {
 	if apiKey != "" {
 		p.APIKey = apiKey
 	}
-
-	survey.AskOne(&survey.Input{Message: "Model:", Default: p.Model}, &p.Model)
-	survey.AskOne(&survey.Input{Message: "Small model:", Default: p.SmallModel}, &p.SmallModel)
-	survey.AskOne(&survey.Input{Message: "Sonnet model:", Default: p.SonnetModel}, &p.SonnetModel)
-	survey.AskOne(&survey.Input{Message: "Opus model:", Default: p.OpusModel}, &p.OpusModel)
-	survey.AskOne(&survey.Input{Message: "Haiku model:", Default: p.HaikuModel}, &p.HaikuModel)
-
-	var timeoutStr string
-	survey.AskOne(&survey.Input{Message: "Timeout ms:", Default: strconv.Itoa(p.Timeout)}, &timeoutStr)
-	if timeout, err := strconv.Atoi(timeoutStr); err == nil {
-		p.Timeout = timeout
-	}
+	p.APIFormat = askAPIFormat(p.APIFormat)
+
+	ids := discoverModelIDs(p)
+	p.Model = askModel("Model:", p.Model, "(Claude default)", ids)
+	p.SmallModel = askModel("Small model:", p.SmallModel, "(same as main)", ids)
+	p.SonnetModel = askModel("Sonnet model:", p.SonnetModel, "(same as main)", ids)
+	p.OpusModel = askModel("Opus model:", p.OpusModel, "(same as main)", ids)
+	p.HaikuModel = askModel("Haiku model:", p.HaikuModel, "(same as main)", ids)
+
+	editTimeout(p)
+	editEnv(p)
+	editNonessentialTraffic(p)
+	editSettingsOverlay(p)
 }
 
 func editField(p *config.Provider, fieldIndex int) {
@@ -141,7 +150,7 @@ func editField(p *config.Provider, fieldIndex int) {
 	case 1:
 		survey.AskOne(&survey.Input{Message: "Name:", Default: p.Name}, &p.Name)
 	case 2:
-		survey.AskOne(&survey.Input{Message: "Alias:", Default: p.Alias}, &p.Alias)
+		editAlias(p)
 	case 3:
 		survey.AskOne(&survey.Input{Message: "Base URL:", Default: p.BaseURL}, &p.BaseURL)
 	case 4:
@@ -151,30 +160,79 @@ func editField(p *config.Provider, fieldIndex int) {
 			p.APIKey = apiKey
 		}
 	case 5:
-		survey.AskOne(&survey.Input{Message: "Model:", Default: p.Model}, &p.Model)
+		p.Model = askModel("Model:", p.Model, "(Claude default)", discoverModelIDs(p))
 	case 6:
-		survey.AskOne(&survey.Input{Message: "Small model:", Default: p.SmallModel}, &p.SmallModel)
+		p.SmallModel = askModel("Small model:", p.SmallModel, "(same as main)", discoverModelIDs(p))
 	case 7:
-		survey.AskOne(&survey.Input{Message: "Sonnet model:", Default: p.SonnetModel}, &p.SonnetModel)
+		p.SonnetModel = askModel("Sonnet model:", p.SonnetModel, "(same as main)", discoverModelIDs(p))
 	case 8:
-		survey.AskOne(&survey.Input{Message: "Opus model:", Default: p.OpusModel}, &p.OpusModel)
+		p.OpusModel = askModel("Opus model:", p.OpusModel, "(same as main)", discoverModelIDs(p))
 	case 9:
-		survey.AskOne(&survey.Input{Message: "Haiku model:", Default: p.HaikuModel}, &p.HaikuModel)
+		p.HaikuModel = askModel("Haiku model:", p.HaikuModel, "(same as main)", discoverModelIDs(p))
 	case 10:
-		var timeoutStr string
-		survey.AskOne(&survey.Input{Message: "Timeout ms:", Default: strconv.Itoa(p.Timeout)}, &timeoutStr)
-		if timeout, err := strconv.Atoi(timeoutStr); err == nil {
-			p.Timeout = timeout
+		editTimeout(p)
+	case 11:
+		editEnv(p)
+	case 12:
+		editNonessentialTraffic(p)
+	case 13:
+		editSettingsOverlay(p)
+	case 14:
+		p.APIFormat = askAPIFormat(p.APIFormat)
+	}
+}
+
+func editAlias(p *config.Provider) {
+	current := p.Alias
+	validate := func(ans interface{}) error {
+		// Existing aliases predating the rules can be kept
+		if alias := ans.(string); alias != current && config.ValidateAlias(alias) != nil {
+			return errors.New("use letters, digits, '.', '_' and '-'")
 		}
+		return nil
+	}
+	var alias string
+	if err := survey.AskOne(&survey.Input{Message: "Alias:", Default: p.Alias}, &alias, survey.WithValidator(validate)); err != nil {
+		return
 	}
+	p.Set("alias", alias)
 }
 
-func updateClaudeSettings(p *config.Provider) error {
-	settings, err := claude.LoadSettings()
-	if err != nil {
-		return err
+func editTimeout(p *config.Provider) {
+	validate := func(ans interface{}) error {
+		var scratch config.Provider
+		return scratch.Set("timeout_ms", ans.(string))
+	}
+	var timeoutStr string
+	if err := survey.AskOne(&survey.Input{Message: "Timeout ms:", Default: strconv.Itoa(p.Timeout)}, &timeoutStr, survey.WithValidator(validate)); err != nil {
+		return
+	}
+	p.Set("timeout_ms", timeoutStr)
+}
+
+func editNonessentialTraffic(p *config.Provider) {
+	disable := p.DisablesNonessentialTraffic()
+	prompt := &survey.Confirm{Message: "Disable nonessential traffic?", Default: disable}
+	if err := survey.AskOne(prompt, &disable); err != nil {
+		return
+	}
+	p.DisableNonessentialTraffic = &disable
+}
+
+func editSettingsOverlay(p *config.Provider) {
+	settingsStr := formatSettingsOverlay(p.Settings)
+	prompt := &survey.Multiline{Message: "Settings overlay (JSON object, empty=none):", Default: settingsStr}
+	if err := survey.AskOne(prompt, &settingsStr, survey.WithValidator(validateSettingsOverlay)); err != nil {
+		return
+	}
+	p.Settings, _ = parseSettingsOverlay(settingsStr)
+}
+
+func editEnv(p *config.Provider) {
+	envStr := formatEnvLines(p.Env)
+	prompt := &survey.Multiline{Message: "Extra env vars (KEY=VALUE per line):", Default: envStr}
+	if err := survey.AskOne(prompt, &envStr, survey.WithValidator(validateEnvLines)); err != nil {
+		return
 	}
-	settings.ClearProviderSettings()
-	settings.ApplyProvider(p)
-	return settings.Save()
+	p.Env, _ = parseEnvLines(envStr)
 }
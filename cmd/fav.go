@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// favoriteMark is shown next to favorite providers
+const favoriteMark = "★"
+
+var favCmd = &cobra.Command{
+	Use:   "fav",
+	Short: "List favorites or mark providers as favorites",
+	Run:   runFavList,
+}
+
+var favAddCmd = &cobra.Command{
+	Use:   "add <alias>...",
+	Short: "Mark providers as favorites, listed first",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setFavorites(args, true)
+	},
+}
+
+var favRmCmd = &cobra.Command{
+	Use:     "rm <alias>...",
+	Aliases: []string{"remove"},
+	Short:   "Unmark favorite providers",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setFavorites(args, false)
+	},
+}
+
+func init() {
+	favCmd.AddCommand(favAddCmd)
+	favCmd.AddCommand(favRmCmd)
+}
+
+// runFavList prints the favorite providers
+func runFavList(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	shown := false
+	for _, p := range cfg.Ordered() {
+		if p.Favorite {
+			fmt.Printf("%s %s (%s)\n", favoriteMark, p.Name, p.Alias)
+			shown = true
+		}
+	}
+	if !shown {
+		color.Yellow("No favorites. Use 'ccs fav add <alias>' to add one.")
+	}
+}
+
+// setFavorites marks or unmarks providers as favorites
+func setFavorites(aliases []string, favorite bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	var providers []*config.Provider
+	for _, alias := range aliases {
+		p, err := cfg.GetProvider(resolveAlias(cfg, alias))
+		if err != nil {
+			color.Red("Provider '%s' not found", alias)
+			return
+		}
+		providers = append(providers, p)
+	}
+	for _, p := range providers {
+		p.Favorite = favorite
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+
+	for _, p := range providers {
+		if favorite {
+			color.Green("'%s' is a favorite", p.Alias)
+		} else {
+			color.Green("'%s' is no longer a favorite", p.Alias)
+		}
+	}
+}
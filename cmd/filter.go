@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// providerFilter is one --filter expression: field, operator and value
+type providerFilter struct {
+	field  string
+	op     string // "~" contains, "=" equals, "!~" and "!=" negate them
+	value  string
+	negate bool
+}
+
+// filterFields are the provider fields --filter can match
+var filterFields = []string{"alias", "name", "url", "host", "model", "format", "tag"}
+
+// parseFilter parses an expression such as "url~openrouter" or "format=openai".
+// The expression is split at its first operator, so values may contain "~"
+// or "=". Matching is case-insensitive; "model" and "tag" match any of the
+// provider's models or tags
+func parseFilter(expr string) (providerFilter, error) {
+	i := strings.IndexAny(expr, "~=")
+	if i < 0 {
+		return providerFilter{}, fmt.Errorf("invalid filter %q, expected field~value or field=value", expr)
+	}
+	field, op, value := expr[:i], expr[i:i+1], expr[i+1:]
+	negate := strings.HasSuffix(field, "!")
+	if negate {
+		field = strings.TrimSuffix(field, "!")
+	}
+
+	field = strings.ToLower(strings.TrimSpace(field))
+	if !contains(filterFields, field) {
+		return providerFilter{}, fmt.Errorf("unknown filter field %q, expected one of %s", field, strings.Join(filterFields, ", "))
+	}
+	return providerFilter{
+		field:  field,
+		op:     op,
+		value:  strings.ToLower(strings.TrimSpace(value)),
+		negate: negate,
+	}, nil
+}
+
+// match reports whether a provider satisfies the filter
+func (f providerFilter) match(p *config.Provider) bool {
+	var values []string
+	switch f.field {
+	case "alias":
+		values = []string{p.Alias}
+	case "name":
+		values = []string{p.Name}
+	case "url":
+		values = []string{p.BaseURL}
+	case "host":
+		values = []string{providerHost(p)}
+	case "model":
+		values = []string{p.Model, p.SmallModel, p.SonnetModel, p.OpusModel, p.HaikuModel}
+	case "format":
+		format := p.APIFormat
+		if format == "" {
+			format = config.FormatAnthropic
+		}
+		values = []string{format}
+	case "tag":
+		values = p.Tags
+	}
+
+	matched := false
+	for _, v := range values {
+		v = strings.ToLower(v)
+		if (f.op == "~" && strings.Contains(v, f.value)) || (f.op == "=" && v == f.value) {
+			matched = true
+			break
+		}
+	}
+	return matched != f.negate
+}
+
+// filterProviders returns the providers matching every filter expression
+func filterProviders(providers []config.Provider, exprs []string) ([]config.Provider, error) {
+	filters := make([]providerFilter, len(exprs))
+	for i, expr := range exprs {
+		f, err := parseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = f
+	}
+
+	var matched []config.Provider
+	for i := range providers {
+		ok := true
+		for _, f := range filters {
+			if !f.match(&providers[i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, providers[i])
+		}
+	}
+	return matched, nil
+}
+
+// sortKeys are the accepted --sort values
+var sortKeys = []string{"alias", "name", "host", "timeout", "used"}
+
+// sortProviders sorts providers in place by key. "used" puts the most
+// recently used first and never-used providers last. An empty key keeps the
+// configured order
+func sortProviders(providers []config.Provider, key string) error {
+	var less func(a, b *config.Provider) bool
+	switch key {
+	case "":
+		return nil
+	case "alias":
+		less = func(a, b *config.Provider) bool { return a.Alias < b.Alias }
+	case "name":
+		less = func(a, b *config.Provider) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "host":
+		less = func(a, b *config.Provider) bool { return providerHost(a) < providerHost(b) }
+	case "timeout":
+		less = func(a, b *config.Provider) bool { return a.Timeout < b.Timeout }
+	case "used":
+		less = func(a, b *config.Provider) bool {
+			if a.LastUsed == nil || b.LastUsed == nil {
+				return a.LastUsed != nil
+			}
+			return a.LastUsed.After(*b.LastUsed)
+		}
+	default:
+		return fmt.Errorf("unknown sort key %q, expected one of %s", key, strings.Join(sortKeys, ", "))
+	}
+
+	sort.SliceStable(providers, func(i, j int) bool {
+		return less(&providers[i], &providers[j])
+	})
+	return nil
+}
+
+// providerHost returns the host of a provider's base URL
+func providerHost(p *config.Provider) string {
+	u, err := url.Parse(p.BaseURL)
+	if err != nil || u.Host == "" {
+		return p.BaseURL
+	}
+	return u.Host
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import "testing"
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want providerFilter
+		err  bool
+	}{
+		{expr: "url~openrouter", want: providerFilter{field: "url", op: "~", value: "openrouter"}},
+		{expr: "Format = OpenAI", want: providerFilter{field: "format", op: "=", value: "openai"}},
+		{expr: "host!~example", want: providerFilter{field: "host", op: "~", value: "example", negate: true}},
+		{expr: "tag!=cn", want: providerFilter{field: "tag", op: "=", value: "cn", negate: true}},
+		{expr: "url=https://x.example.com/~user", want: providerFilter{field: "url", op: "=", value: "https://x.example.com/~user"}},
+		{expr: "url~?key=value", want: providerFilter{field: "url", op: "~", value: "?key=value"}},
+		{expr: "name~a!=b", want: providerFilter{field: "name", op: "~", value: "a!=b"}},
+		{expr: "url", err: true},
+		{expr: "size=1", err: true},
+		{expr: "=openai", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseFilter(tt.expr)
+		if (err != nil) != tt.err {
+			t.Errorf("parseFilter(%q) error = %v, want error %v", tt.expr, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseFilter(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
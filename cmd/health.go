@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/display"
+	"github.com/katz/ccs/internal/health"
+	"github.com/katz/ccs/internal/probe"
+	"github.com/katz/ccs/internal/usage"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var healthCmd = &cobra.Command{
+	Use:   "health [alias...]",
+	Short: "Probe providers and show latency and error history",
+	Long: `Probe providers, record the results in ~/.config/ccs/health.jsonl and show
+p50/p95 latency, error rate and the last failure over the recorded history.
+By default only connectivity is checked (GET /v1/models); --full also sends
+a one-token request per configured model, which measures inference latency
+but may cost tokens. The stats only cover probes of the same kind, since
+the two latencies are not comparable.`,
+	Run: runHealth,
+}
+
+var (
+	healthWatch    bool
+	healthInterval time.Duration
+	healthSince    string
+	healthFull     bool
+	healthJSON     bool
+)
+
+func init() {
+	healthCmd.Flags().BoolVarP(&healthWatch, "watch", "w", false, "Keep probing and refresh the table every interval")
+	healthCmd.Flags().DurationVar(&healthInterval, "interval", time.Minute, "Probe interval with --watch")
+	healthCmd.Flags().StringVar(&healthSince, "since", "24h", "History window, e.g. 1h, 24h, 7d")
+	healthCmd.Flags().BoolVar(&healthFull, "full", false, "Also probe each configured model")
+	healthCmd.Flags().BoolVar(&healthJSON, "json", false, "Print the stats as JSON")
+}
+
+func runHealth(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	providers := cfg.Providers
+	if len(args) > 0 {
+		providers = nil
+		for _, alias := range args {
+			p, err := cfg.GetProvider(resolveAlias(cfg, alias))
+			if err != nil {
+				color.Red("Provider '%s' not found", alias)
+				return
+			}
+			providers = append(providers, *p)
+		}
+	}
+	if len(providers) == 0 {
+		color.Yellow("No providers configured")
+		return
+	}
+
+	aliases := make([]string, len(providers))
+	for i, p := range providers {
+		aliases[i] = p.Alias
+	}
+
+	store, err := health.Open()
+	if err != nil {
+		color.Red("Failed to open health history: %v", err)
+		return
+	}
+	if err := store.Prune(time.Now().Add(-health.Retention)); err != nil {
+		color.Yellow("Warning: failed to prune health history: %v", err)
+	}
+
+	if healthWatch && healthInterval <= 0 {
+		color.Red("--interval must be positive")
+		return
+	}
+
+	prober := &probe.Prober{PingOnly: !healthFull}
+	mode := health.ProbeMode(healthFull)
+	clear := healthWatch && !healthJSON && term.IsTerminal(int(os.Stdout.Fd()))
+	for {
+		since, err := usage.ParseSince(healthSince, time.Now())
+		if err != nil {
+			color.Red("%v", err)
+			return
+		}
+
+		results := prober.ProbeAll(context.Background(), providers)
+		samples := make([]health.Sample, len(results))
+		for i, r := range results {
+			samples[i] = health.FromResult(r, mode)
+		}
+		if err := store.Append(samples); err != nil {
+			color.Red("Failed to record health: %v", err)
+			return
+		}
+
+		history, err := store.Load(since)
+		if err != nil {
+			color.Red("Failed to load health history: %v", err)
+			return
+		}
+		stats := health.Summarize(history, aliases, mode)
+
+		if clear {
+			// Written through color.Output, which handles ANSI on Windows
+			fmt.Fprint(color.Output, "\033[H\033[2J")
+		}
+		if healthJSON {
+			data, err := json.MarshalIndent(stats, "", "  ")
+			if err != nil {
+				color.Red("Failed to encode health: %v", err)
+				return
+			}
+			fmt.Println(string(data))
+		} else {
+			printHealthTable(stats, cfg.CurrentProvider, mode, since)
+		}
+
+		if !healthWatch {
+			return
+		}
+		time.Sleep(healthInterval)
+	}
+}
+
+// printHealthTable prints one row per provider, red if its last probe failed
+func printHealthTable(stats []health.Stats, current string, mode health.Mode, since time.Time) {
+	width := len("provider")
+	for _, st := range stats {
+		if len(st.Provider)+2 > width {
+			width = len(st.Provider) + 2
+		}
+	}
+
+	fmt.Printf("Health of %s probes since %s\n", mode, since.Format("2006-01-02 15:04"))
+	fmt.Printf("%-*s  %7s  %6s  %8s  %8s  %-14s  %s\n", width, "provider", "samples", "errors", "p50", "p95", "last", "last failure")
+
+	now := time.Now()
+	for _, st := range stats {
+		name := st.Provider
+		if name == current {
+			name += " *"
+		}
+
+		last, lastFailure := "-", "-"
+		if st.Last != nil {
+			if st.Last.OK {
+				last = fmt.Sprintf("ok %dms", st.Last.LatencyMS)
+			} else {
+				last = "failed"
+			}
+		}
+		if st.LastFailure != nil {
+			lastFailure = formatAgo(now.Sub(st.LastFailure.Time)) + ": " + truncate(st.LastFailure.Error, 60)
+		}
+
+		row := fmt.Sprintf("%-*s  %7d  %5.1f%%  %8s  %8s  %-14s  %s",
+			width, name, st.Samples, st.ErrorRate*100, formatLatency(st.P50MS), formatLatency(st.P95MS), last, lastFailure)
+		if st.Last != nil && !st.Last.OK {
+			color.Red("%s", row)
+		} else {
+			fmt.Println(row)
+		}
+	}
+}
+
+// formatLatency formats a latency, "-" if there is none
+func formatLatency(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", ms)
+}
+
+// formatAgo formats a duration as a short relative time
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// truncate shortens s to at most n terminal columns, marking the cut with "..."
+func truncate(s string, n int) string {
+	return display.Truncate(strings.TrimSpace(s), n, "...")
+}
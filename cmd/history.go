@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent provider switches",
+	Long: `Show recent provider switches, newest first. @{N} refers to the Nth switch
+before the latest one, so 'ccs use @{1}' (or 'ccs use -') switches back to
+the previous provider and 'ccs use @{2}' to the one before it.`,
+	Args: cobra.NoArgs,
+	Run:  runHistory,
+}
+
+var historyLimit int
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of switches to show, 0 for all")
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	history, err := config.LoadHistory()
+	if err != nil {
+		color.Red("Failed to load history: %v", err)
+		return
+	}
+	if len(history) == 0 {
+		color.Yellow("No switches recorded yet")
+		return
+	}
+
+	now := time.Now()
+	var rows [][]string
+	for n := 0; n < len(history); n++ {
+		if historyLimit > 0 && n >= historyLimit {
+			break
+		}
+		e := history[len(history)-1-n]
+		name := "(removed)"
+		if p, err := cfg.GetProvider(e.Alias); err == nil {
+			name = p.Name
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("@{%d}", n), e.Alias, name, e.Scope,
+			e.Time.Format("2006-01-02 15:04"), formatAgo(now.Sub(e.Time)),
+		})
+	}
+
+	header := []string{"REF", "ALIAS", "NAME", "SCOPE", "SWITCHED", ""}
+	for i, line := range renderTable(header, rows, []int{2}, terminalWidth()) {
+		if i == 1 && history[len(history)-1].Alias == cfg.CurrentProvider {
+			color.Green("%s", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+// isHistoryRef reports whether a 'ccs use' argument refers to the history
+// rather than naming a provider
+func isHistoryRef(arg string) bool {
+	return arg == "-" || strings.HasPrefix(arg, "@{")
+}
+
+// resolveHistoryRef returns the alias a history reference points at: '-' is
+// the previous provider and @{N} the Nth switch before the latest
+func resolveHistoryRef(ref string) (string, error) {
+	n := 1
+	if ref != "-" {
+		inner, ok := strings.CutSuffix(strings.TrimPrefix(ref, "@{"), "}")
+		parsed, err := strconv.Atoi(inner)
+		if !ok || err != nil || parsed < 0 {
+			return "", fmt.Errorf("invalid history reference '%s', expected - or @{N}", ref)
+		}
+		n = parsed
+	}
+
+	history, err := config.LoadHistory()
+	if err != nil {
+		return "", fmt.Errorf("failed to load history: %w", err)
+	}
+	if n >= len(history) {
+		if n == 1 {
+			return "", fmt.Errorf("no previous provider in the history")
+		}
+		return "", fmt.Errorf("the history has only %d switches", len(history))
+	}
+	return history[len(history)-1-n].Alias, nil
+}
+
+// recordSwitch adds a switch to the history, warning if it cannot be saved
+func recordSwitch(alias string) {
+	if err := config.RecordSwitch(alias, config.ScopeUser); err != nil {
+		color.Yellow("Warning: Failed to record history: %v", err)
+	}
+}
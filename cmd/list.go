@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/katz/ccs/internal/config"
@@ -13,43 +15,178 @@ var listCmd = &cobra.Command{
 	Use:     "list [alias]",
 	Aliases: []string{"ls"},
 	Short:   "List providers or show provider details (alias: ls)",
-	Run:     runList,
+	RunE:    runList,
 }
 
-func runList(cmd *cobra.Command, args []string) {
+var (
+	listLong    bool
+	listSort    string
+	listFilters []string
+	listTags    []string
+	listGroup   bool
+)
+
+func init() {
+	listCmd.Flags().BoolVarP(&listLong, "long", "l", false, "Show a table with name, host, models, timeout and last use")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "Sort by "+strings.Join(sortKeys, ", "))
+	listCmd.Flags().StringArrayVar(&listTags, "tag", nil, "Only providers with this tag (repeatable, all must match)")
+	listCmd.Flags().BoolVarP(&listGroup, "group", "g", false, "Group providers by tag")
+	listCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, yaml or table")
+	listCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show API keys, env values and settings values in json/yaml output")
+	listCmd.Flags().BoolVarP(&quietOutput, "quiet", "q", false, "Print only provider aliases")
+	listCmd.Flags().StringArrayVar(&listFilters, "filter", nil, "Filter such as 'url~openrouter' or 'format=openai' (fields: "+strings.Join(filterFields, ", ")+"; repeatable)")
+}
+
+func runList(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
 		color.Red("Failed to load config: %v", err)
-		return
+		return errReported
+	}
+
+	if err := validateOutputFormat(); err != nil {
+		color.Red("%v", err)
+		return errReported
 	}
 
 	if len(cfg.Providers) == 0 {
-		color.Yellow("No providers configured. Use 'ccs add' to add one.")
-		return
+		if outputFormat == outputJSON || outputFormat == outputYAML {
+			printProviders(cfg, nil, false)
+			return nil
+		}
+		if !quietOutput {
+			color.Yellow("No providers configured. Use 'ccs add' to add one.")
+		}
+		return nil
 	}
 
 	// If alias provided, show details for that provider
 	if len(args) > 0 {
-		showProviderDetail(cfg, args[0])
-		return
+		if !showProviderDetail(cfg, args[0]) {
+			return errReported
+		}
+		return nil
+	}
+
+	providers, err := filterProviders(cfg.Ordered(), listFilters)
+	if err != nil {
+		color.Red("%v", err)
+		return errReported
+	}
+	providers = withTags(providers, listTags)
+	if err := sortProviders(providers, listSort); err != nil {
+		color.Red("%v", err)
+		return errReported
+	}
+
+	if outputFormat != "" || quietOutput {
+		if err := printProviders(cfg, providers, false); err != nil {
+			color.Red("Failed to print providers: %v", err)
+			return errReported
+		}
+		return nil
+	}
+
+	if len(providers) == 0 {
+		color.Yellow("No providers match the filter")
+		return nil
+	}
+
+	var groups []tagGroup
+	if listGroup {
+		groups = groupByTag(providers)
+	} else {
+		groups = []tagGroup{{Providers: providers}}
+	}
+
+	if listLong {
+		printLongList(groups, cfg.CurrentProvider)
+		return nil
 	}
 
 	// List all providers (alias only)
-	for _, p := range cfg.Providers {
-		isCurrent := p.Alias == cfg.CurrentProvider
-		if isCurrent {
-			color.Green("* %s", p.Alias)
-		} else {
-			fmt.Printf("  %s\n", p.Alias)
+	for _, g := range groups {
+		if g.Tag != "" {
+			fmt.Printf("%s:\n", g.Tag)
+		}
+		for _, p := range g.Providers {
+			label := p.Alias
+			if p.Favorite {
+				label += " " + favoriteMark
+			}
+			isCurrent := p.Alias == cfg.CurrentProvider
+			if isCurrent {
+				color.Green("* %s", label)
+			} else {
+				fmt.Printf("  %s\n", label)
+			}
 		}
 	}
+	return nil
 }
 
-func showProviderDetail(cfg *config.Config, alias string) {
+// printLongList prints a table of providers fitted to the terminal width,
+// with the current provider marked and highlighted. Named groups get a
+// heading, and all groups share the column widths
+func printLongList(groups []tagGroup, current string) {
+	header := []string{" ", "ALIAS", "NAME", "HOST", "MODELS", "TIMEOUT", "LAST USED", "TAGS"}
+	var rows [][]string
+	var aliases []string
+	for _, g := range groups {
+		for i := range g.Providers {
+			p := &g.Providers[i]
+			marker := " "
+			if p.Alias == current {
+				marker = "*"
+			}
+			if p.Favorite {
+				marker += favoriteMark
+			}
+			lastUsed := "never"
+			if p.LastUsed != nil {
+				lastUsed = formatAgo(time.Since(*p.LastUsed))
+			}
+			rows = append(rows, []string{marker, p.Alias, p.Name, providerHost(p), buildModelLine(*p),
+				fmt.Sprintf("%dms", p.Timeout), lastUsed, strings.Join(p.Tags, ",")})
+			aliases = append(aliases, p.Alias)
+		}
+	}
+
+	// Name, host, models and tags give way first on narrow terminals
+	lines := renderTable(header, rows, []int{2, 3, 4, 7}, terminalWidth())
+	fmt.Println(lines[0])
+	row := 0
+	for _, g := range groups {
+		if g.Tag != "" {
+			fmt.Printf("%s:\n", g.Tag)
+		}
+		for range g.Providers {
+			if aliases[row] == current {
+				color.Green("%s", lines[row+1])
+			} else {
+				fmt.Println(lines[row+1])
+			}
+			row++
+		}
+	}
+}
+
+// showProviderDetail prints one provider, reporting false if it was not
+// found or could not be printed
+func showProviderDetail(cfg *config.Config, alias string) bool {
+	alias = resolveAlias(cfg, alias)
 	p, err := cfg.GetProvider(alias)
 	if err != nil {
 		color.Red("Provider '%s' not found", alias)
-		return
+		return false
+	}
+
+	if outputFormat != "" || quietOutput {
+		if err := printProviders(cfg, []config.Provider{*p}, true); err != nil {
+			color.Red("Failed to print provider: %v", err)
+			return false
+		}
+		return true
 	}
 
 	isCurrent := p.Alias == cfg.CurrentProvider
@@ -63,8 +200,65 @@ func showProviderDetail(cfg *config.Config, alias string) {
 
 	// Details
 	printDetail("URL", p.BaseURL, isCurrent)
+	if len(p.Tags) > 0 {
+		printDetail("Tags", strings.Join(p.Tags, ", "), isCurrent)
+	}
+	if p.IsOpenAI() {
+		printDetail("API", "openai (translated by ccs proxy)", isCurrent)
+	}
 	printDetail("Models", buildModelLine(*p), isCurrent)
 	printDetail("Timeout", fmt.Sprintf("%dms", p.Timeout), isCurrent)
+	if len(p.Env) > 0 {
+		printDetail("Env", buildEnvLine(*p), isCurrent)
+	}
+	if len(p.Settings) > 0 {
+		printDetail("Settings", buildSettingsLine(*p), isCurrent)
+	}
+	if p.Budget != nil {
+		printDetail("Budget", buildBudgetLine(*p.Budget), isCurrent)
+	}
+	return true
+}
+
+// buildBudgetLine summarizes the configured budget limits
+func buildBudgetLine(b config.Budget) string {
+	var parts []string
+	if b.DailyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("daily %d tokens", b.DailyTokens))
+	}
+	if b.MonthlyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("monthly %d tokens", b.MonthlyTokens))
+	}
+	if b.DailyCost > 0 {
+		parts = append(parts, fmt.Sprintf("daily $%.2f", b.DailyCost))
+	}
+	if b.MonthlyCost > 0 {
+		parts = append(parts, fmt.Sprintf("monthly $%.2f", b.MonthlyCost))
+	}
+	if b.Hard {
+		parts = append(parts, "hard")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// buildSettingsLine lists the top-level keys of the settings overlay
+func buildSettingsLine(p config.Provider) string {
+	keys := make([]string, 0, len(p.Settings))
+	for key := range p.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// buildEnvLine lists the extra env keys without their values, which may be secrets
+func buildEnvLine(p config.Provider) string {
+	keys := make([]string, 0, len(p.Env))
+	for key := range p.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
 }
 
 func printDetail(label, value string, isCurrent bool) {
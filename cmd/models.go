@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var modelsCmd = &cobra.Command{
+	Use:     "models [alias]",
+	Aliases: []string{"m"},
+	Short:   "List models offered by a provider (alias: m)",
+	Run:     runModels,
+}
+
+var (
+	modelsRefresh bool
+	modelsJSON    bool
+)
+
+// discoverTimeout bounds model discovery during interactive prompts
+const discoverTimeout = 10 * time.Second
+
+// manualModelOption falls back to free text in the model select
+const manualModelOption = "(enter manually)"
+
+func init() {
+	modelsCmd.Flags().BoolVarP(&modelsRefresh, "refresh", "r", false, "Ignore the cache and query the provider")
+	modelsCmd.Flags().BoolVar(&modelsJSON, "json", false, "Print models as JSON")
+}
+
+func runModels(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	var p *config.Provider
+	if len(args) > 0 {
+		p, err = cfg.GetProvider(resolveAlias(cfg, args[0]))
+		if err != nil {
+			color.Red("Provider '%s' not found", args[0])
+			return
+		}
+	} else {
+		p, err = cfg.GetCurrentProvider()
+		if err != nil {
+			color.Yellow("No current provider, pass an alias")
+			return
+		}
+	}
+
+	list, err := models.Discover(context.Background(), p, modelsRefresh)
+	if err != nil {
+		color.Red("Failed to list models: %v", err)
+		return
+	}
+
+	if modelsJSON {
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			color.Red("Failed to encode models: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(list) == 0 {
+		color.Yellow("Provider '%s' lists no models", p.Alias)
+		return
+	}
+
+	for _, m := range list {
+		if m.DisplayName != "" && m.DisplayName != m.ID {
+			fmt.Printf("  %s (%s)\n", m.ID, m.DisplayName)
+		} else {
+			fmt.Printf("  %s\n", m.ID)
+		}
+	}
+}
+
+// discoverModelIDs returns the provider's model IDs for prompts, or nil if
+// discovery fails, in which case prompts fall back to free text
+func discoverModelIDs(p *config.Provider) []string {
+	if p.BaseURL == "" || p.APIKey == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoverTimeout)
+	defer cancel()
+
+	list, err := models.Discover(ctx, p, false)
+	if err != nil {
+		color.Yellow("Model discovery unavailable: %v", err)
+		return nil
+	}
+	return models.IDs(list)
+}
+
+// askModel asks for a model, offering a searchable select of discovered
+// models when available. emptyLabel names the option that leaves it empty
+func askModel(message, def, emptyLabel string, ids []string) string {
+	if len(ids) == 0 {
+		value := def
+		survey.AskOne(&survey.Input{Message: message, Default: def}, &value)
+		return value
+	}
+
+	options := append([]string{emptyLabel}, ids...)
+	options = append(options, manualModelOption)
+
+	selected := emptyLabel
+	if def != "" {
+		selected = manualModelOption
+		for _, id := range ids {
+			if id == def {
+				selected = def
+				break
+			}
+		}
+	}
+
+	prompt := &survey.Select{
+		Message: message,
+		Options: options,
+		Default: selected,
+	}
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return def
+	}
+
+	switch selected {
+	case emptyLabel:
+		return ""
+	case manualModelOption:
+		value := def
+		survey.AskOne(&survey.Input{Message: message, Default: def}, &value)
+		return value
+	}
+	return selected
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move <alias> --to N | --before <alias>",
+	Short: "Reorder providers or choose how lists are ordered",
+	Long: `Move a provider to position N (1 is first) or before another provider in
+the configured order, which lists and pickers show after favorites.
+
+--order recent lists the most recently used providers first instead, and
+--order manual goes back to the configured order.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMove,
+}
+
+var (
+	moveTo     int
+	moveBefore string
+	moveOrder  string
+)
+
+func init() {
+	moveCmd.Flags().IntVar(&moveTo, "to", 0, "New position, 1 is first")
+	moveCmd.Flags().StringVar(&moveBefore, "before", "", "Move before this provider")
+	moveCmd.Flags().StringVar(&moveOrder, "order", "", "List order: manual or recent")
+}
+
+func runMove(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	if moveOrder != "" {
+		if len(args) > 0 || moveTo != 0 || moveBefore != "" {
+			color.Red("--order cannot be combined with moving a provider")
+			return
+		}
+		setOrder(cfg, moveOrder)
+		return
+	}
+
+	if len(args) == 0 || (moveTo == 0) == (moveBefore == "") {
+		color.Red("Usage: ccs move <alias> --to N | --before <alias>")
+		return
+	}
+
+	alias := resolveAlias(cfg, args[0])
+	from := cfg.ProviderIndex(alias)
+	if from < 0 {
+		color.Red("Provider '%s' not found", args[0])
+		return
+	}
+
+	var index int
+	if moveBefore != "" {
+		before := resolveAlias(cfg, moveBefore)
+		index = cfg.ProviderIndex(before)
+		if index < 0 {
+			color.Red("Provider '%s' not found", moveBefore)
+			return
+		}
+		if before == alias {
+			return
+		}
+		// Removing the provider first shifts the later ones up
+		if from < index {
+			index--
+		}
+	} else {
+		if moveTo < 1 {
+			color.Red("--to must be 1 or more")
+			return
+		}
+		index = moveTo - 1
+	}
+
+	if err := cfg.MoveProvider(alias, index); err != nil {
+		color.Red("Failed to move: %v", err)
+		return
+	}
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+
+	color.Green("Moved '%s' to position %d", alias, cfg.ProviderIndex(alias)+1)
+	if cfg.Order == config.OrderRecent {
+		color.Yellow("Lists are ordered by recent use, run 'ccs move --order manual' to use this order")
+	}
+}
+
+// setOrder sets how lists and pickers order providers
+func setOrder(cfg *config.Config, order string) {
+	switch order {
+	case "manual":
+		cfg.Order = config.OrderManual
+	case config.OrderRecent:
+		cfg.Order = config.OrderRecent
+	default:
+		color.Red("Unknown order '%s', expected manual or recent", order)
+		return
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+	fmt.Printf("Providers are now listed in %s order\n", order)
+}
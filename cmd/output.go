@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/katz/ccs/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats for --output. The empty format is the default colored text
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+)
+
+// Output flags, registered in list.go. tui shares --show-secrets
+var (
+	outputFormat string
+	showSecrets  bool
+	quietOutput  bool
+)
+
+// validateOutputFormat checks the --output flag
+func validateOutputFormat() error {
+	switch outputFormat {
+	case "", outputJSON, outputYAML, outputTable:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, expected json, yaml or table", outputFormat)
+}
+
+// providerOutput is the stable machine-readable form of a provider
+type providerOutput struct {
+	Name                       string                 `json:"name"`
+	Alias                      string                 `json:"alias"`
+	Current                    bool                   `json:"current"`
+	BaseURL                    string                 `json:"base_url"`
+	APIKey                     string                 `json:"api_key"`
+	APIFormat                  string                 `json:"api_format"`
+	Tags                       []string               `json:"tags"`
+	Favorite                   bool                   `json:"favorite"`
+	Models                     modelsOutput           `json:"models"`
+	TimeoutMS                  int                    `json:"timeout_ms"`
+	DisableNonessentialTraffic bool                   `json:"disable_nonessential_traffic"`
+	Env                        map[string]string      `json:"env,omitempty"`
+	Settings                   map[string]interface{} `json:"settings,omitempty"`
+	Budget                     *config.Budget         `json:"budget,omitempty"`
+}
+
+// modelsOutput lists a provider's models by role, empty if unset
+type modelsOutput struct {
+	Main   string `json:"main"`
+	Small  string `json:"small"`
+	Sonnet string `json:"sonnet"`
+	Opus   string `json:"opus"`
+	Haiku  string `json:"haiku"`
+}
+
+// newProviderOutput converts a provider, masking the API key and every env
+// and settings value unless --show-secrets, since any of them may hold a
+// credential
+func newProviderOutput(p *config.Provider, current string) providerOutput {
+	format := p.APIFormat
+	if format == "" {
+		format = config.FormatAnthropic
+	}
+
+	out := providerOutput{
+		Name:      p.Name,
+		Alias:     p.Alias,
+		Current:   p.Alias == current,
+		BaseURL:   p.BaseURL,
+		APIKey:    p.APIKey,
+		APIFormat: format,
+		Tags:      p.Tags,
+		Favorite:  p.Favorite,
+		Models: modelsOutput{
+			Main:   p.Model,
+			Small:  p.SmallModel,
+			Sonnet: p.SonnetModel,
+			Opus:   p.OpusModel,
+			Haiku:  p.HaikuModel,
+		},
+		TimeoutMS:                  p.Timeout,
+		DisableNonessentialTraffic: p.DisablesNonessentialTraffic(),
+		Budget:                     p.Budget,
+	}
+	if out.Tags == nil {
+		out.Tags = []string{}
+	}
+
+	if len(p.Env) > 0 {
+		out.Env = make(map[string]string, len(p.Env))
+		for key, value := range p.Env {
+			if !showSecrets {
+				value = config.MaskSecret(value)
+			}
+			out.Env[key] = value
+		}
+	}
+	if len(p.Settings) > 0 {
+		out.Settings = p.Settings
+		if !showSecrets {
+			out.Settings = maskSettings(p.Settings).(map[string]interface{})
+		}
+	}
+	if !showSecrets {
+		out.APIKey = config.MaskSecret(out.APIKey)
+	}
+	return out
+}
+
+// maskSettings returns a copy of a settings overlay value with every leaf
+// masked, keeping the keys so the overlay's shape stays visible
+func maskSettings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			masked[key] = maskSettings(value)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, value := range v {
+			masked[i] = maskSettings(value)
+		}
+		return masked
+	case string:
+		return config.MaskSecret(v)
+	case nil:
+		return nil
+	default:
+		return "****"
+	}
+}
+
+// printProviders prints providers in the --output format. single prints one
+// object rather than a list for JSON and YAML
+func printProviders(cfg *config.Config, providers []config.Provider, single bool) error {
+	if quietOutput {
+		for _, p := range providers {
+			fmt.Println(p.Alias)
+		}
+		return nil
+	}
+
+	outputs := make([]providerOutput, len(providers))
+	for i := range providers {
+		outputs[i] = newProviderOutput(&providers[i], cfg.CurrentProvider)
+	}
+
+	var v interface{} = outputs
+	if single && len(outputs) == 1 {
+		v = outputs[0]
+	}
+
+	switch outputFormat {
+	case outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case outputYAML:
+		data, err := marshalYAML(v)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	case outputTable:
+		printProviderTable(outputs)
+	}
+	return nil
+}
+
+// marshalYAML renders v as YAML with the same keys and key order as its JSON
+// form, so both formats stay in sync with the json tags
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, so this keeps the key order of the JSON encoding
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+// clearStyle switches a node tree from JSON's flow style to block style
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+// printProviderTable prints an aligned table with a current marker
+func printProviderTable(outputs []providerOutput) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tALIAS\tURL\tMODELS\tTIMEOUT")
+	for _, o := range outputs {
+		marker := ""
+		if o.Current {
+			marker = "*"
+		}
+		if o.Favorite {
+			marker += favoriteMark
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%dms\n", marker, o.Name, o.Alias, o.BaseURL, summarizeModels(o.Models), o.TimeoutMS)
+	}
+	w.Flush()
+}
+
+// summarizeModels lists the distinct configured models, main model first
+func summarizeModels(m modelsOutput) string {
+	var models []string
+	seen := make(map[string]bool)
+	for _, model := range []string{m.Main, m.Sonnet, m.Opus, m.Haiku, m.Small} {
+		if model != "" && !seen[model] {
+			seen[model] = true
+			models = append(models, model)
+		}
+	}
+	if len(models) == 0 {
+		return "default"
+	}
+	return strings.Join(models, ",")
+}
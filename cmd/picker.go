@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/katz/ccs/internal/config"
+)
+
+// pickerTemplate is survey's select template with the option descriptions
+// moved into a preview pane below the list, showing only the focused one
+const pickerTemplate = `
+{{- define "option"}}
+    {{- if eq .SelectedIndex .CurrentIndex }}{{color .Config.Icons.SelectFocus.Format }}{{ .Config.Icons.SelectFocus.Text }} {{else}}{{color "default"}}  {{end}}
+    {{- .CurrentOpt.Value}}
+    {{- color "reset"}}
+{{end}}
+{{- if .ShowHelp }}{{- color .Config.Icons.Help.Format }}{{ .Config.Icons.Help.Text }} {{ .Help }}{{color "reset"}}{{"\n"}}{{end}}
+{{- color .Config.Icons.Question.Format }}{{ .Config.Icons.Question.Text }} {{color "reset"}}
+{{- color "default+hb"}}{{ .Message }}{{ .FilterMessage }}{{color "reset"}}
+{{- if .ShowAnswer}}{{color "cyan"}} {{.Answer}}{{color "reset"}}{{"\n"}}
+{{- else}}
+  {{- "  "}}{{- color "cyan"}}[Use arrows to move, type to filter]{{color "reset"}}
+  {{- "\n"}}
+  {{- range $ix, $option := .PageEntries}}
+    {{- template "option" $.IterateOption $ix $option}}
+  {{- end}}
+  {{- if lt .SelectedIndex (len .PageEntries)}}
+    {{- color "cyan"}}{{ $.GetDescription (index .PageEntries .SelectedIndex) }}{{color "reset"}}
+  {{- end}}
+{{- end}}`
+
+// pickerState remembers the last provider picked by each command
+type pickerState struct {
+	Last map[string]string `json:"last"`
+}
+
+// getPickerStatePath returns the path of the picker state file
+func getPickerStatePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "picker.json"), nil
+}
+
+// loadPickerState loads the picker state, empty if missing or unreadable
+func loadPickerState() pickerState {
+	state := pickerState{Last: make(map[string]string)}
+	path, err := getPickerStatePath()
+	if err != nil {
+		return state
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	json.Unmarshal(data, &state)
+	if state.Last == nil {
+		state.Last = make(map[string]string)
+	}
+	return state
+}
+
+// save writes the picker state. Failures are ignored since it is only a
+// convenience
+func (s pickerState) save() {
+	path, err := getPickerStatePath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	os.WriteFile(path, data, 0644)
+}
+
+// renamePickerState points the selections remembered for an alias at its
+// new alias
+func renamePickerState(oldAlias, newAlias string) {
+	state := loadPickerState()
+	changed := false
+	for command, alias := range state.Last {
+		if alias == oldAlias {
+			state.Last[command] = newAlias
+			changed = true
+		}
+	}
+	if changed {
+		state.save()
+	}
+}
+
+// pickProvider interactively picks a provider for a command such as "use".
+// It asks for a tag first if any provider has tags, then shows the
+// providers with the current one marked, fuzzy filtering on name, alias,
+// URL and tags and a preview of the focused provider. The list starts at
+// the provider last picked for the same command. ok is false if the prompt
+// was cancelled
+func pickProvider(cfg *config.Config, command string) (alias string, ok bool) {
+	candidates, ok := selectByTag(cfg)
+	if !ok {
+		return "", false
+	}
+
+	state := loadPickerState()
+	options := make([]string, len(candidates))
+	defaultIndex := -1
+	for i, p := range candidates {
+		marker := "  "
+		if p.Alias == cfg.CurrentProvider {
+			marker = "* "
+			if defaultIndex < 0 {
+				defaultIndex = i
+			}
+		}
+		options[i] = fmt.Sprintf("%s%s (%s)", marker, p.Name, p.Alias)
+		if p.Favorite {
+			options[i] += " " + favoriteMark
+		}
+		if p.Alias == state.Last[command] {
+			defaultIndex = i
+		}
+	}
+	if defaultIndex < 0 {
+		defaultIndex = 0
+	}
+
+	prompt := &survey.Select{
+		Message: "Select provider:",
+		Options: options,
+		Default: defaultIndex,
+		Filter: func(filter, value string, index int) bool {
+			return pickerMatch(filter, &candidates[index])
+		},
+		Description: func(value string, index int) string {
+			return providerPreview(&candidates[index])
+		},
+	}
+
+	// survey renders every Select with the package-level template
+	saved := survey.SelectQuestionTemplate
+	survey.SelectQuestionTemplate = pickerTemplate
+	defer func() { survey.SelectQuestionTemplate = saved }()
+
+	var selected int
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return "", false
+	}
+
+	alias = candidates[selected].Alias
+	state.Last[command] = alias
+	state.save()
+	return alias, true
+}
+
+// pickerMatch reports whether the picker filter fuzzy matches a provider's
+// name, alias, URL or one of its tags. Each field is matched on its own so
+// a filter cannot pick its characters from several fields
+func pickerMatch(filter string, p *config.Provider) bool {
+	fields := append([]string{p.Name, p.Alias, p.BaseURL}, p.Tags...)
+	for _, field := range fields {
+		if fuzzyMatch(filter, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatch reports whether the characters of filter appear in text in
+// order, ignoring case and spaces in the filter
+func fuzzyMatch(filter, text string) bool {
+	text = strings.ToLower(text)
+	for _, r := range strings.ToLower(filter) {
+		if r == ' ' {
+			continue
+		}
+		i := strings.IndexRune(text, r)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(string(r)):]
+	}
+	return true
+}
+
+// providerPreview describes a provider for the picker's preview pane. The
+// API key is always masked
+func providerPreview(p *config.Provider) string {
+	lines := []string{
+		"URL:     " + p.BaseURL,
+		"Models:  " + buildModelLine(*p),
+		fmt.Sprintf("Timeout: %dms", p.Timeout),
+		"Key:     " + config.MaskSecret(p.APIKey),
+	}
+	if p.IsOpenAI() {
+		lines = append(lines, "API:     "+config.FormatOpenAI)
+	}
+	if len(p.Tags) > 0 {
+		lines = append(lines, "Tags:    "+strings.Join(p.Tags, ", "))
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String()
+}
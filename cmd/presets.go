@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/preset"
+	"github.com/spf13/cobra"
+)
+
+var presetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "List or show built-in provider presets",
+}
+
+var presetsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List available presets (alias: ls)",
+	Run:     runPresetsList,
+}
+
+var presetsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show preset details",
+	Args:  cobra.ExactArgs(1),
+	Run:   runPresetsShow,
+}
+
+func init() {
+	presetsCmd.AddCommand(presetsListCmd)
+	presetsCmd.AddCommand(presetsShowCmd)
+}
+
+func runPresetsList(cmd *cobra.Command, args []string) {
+	presets, err := preset.Load()
+	if err != nil {
+		color.Red("Failed to load presets: %v", err)
+		return
+	}
+
+	width := 0
+	for _, p := range presets {
+		if len(p.Name) > width {
+			width = len(p.Name)
+		}
+	}
+
+	for _, p := range presets {
+		marker := ""
+		if !p.Builtin {
+			marker = " [user]"
+		}
+		fmt.Printf("  %-*s  %s%s\n", width, p.Name, p.Description, marker)
+	}
+}
+
+func runPresetsShow(cmd *cobra.Command, args []string) {
+	p, err := preset.Get(args[0])
+	if err != nil {
+		if err == preset.ErrPresetNotFound {
+			color.Red("Preset '%s' not found", args[0])
+		} else {
+			color.Red("Failed to load presets: %v", err)
+		}
+		return
+	}
+
+	provider := p.Provider()
+	fmt.Printf("%s (%s)\n", p.DisplayName, p.Name)
+	printDetail("Description", p.Description, false)
+	printDetail("URL", provider.BaseURL, false)
+	printDetail("Models", buildModelLine(provider), false)
+	printDetail("Timeout", fmt.Sprintf("%dms", provider.Timeout), false)
+}
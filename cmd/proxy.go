@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/capture"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/proxy"
+	"github.com/katz/ccs/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local proxy that forwards to the current provider",
+	Long: `Run a local proxy that forwards Claude Code requests to the provider
+currently selected with 'ccs use'. Switching providers takes effect on the
+next request, so running Claude Code sessions never need restarting.
+
+Run 'ccs proxy enable' once so Claude Code is pointed at the proxy.
+
+Clients must send the random token from 'ccs proxy token' as their API key,
+which 'ccs use' writes to settings.json. The proxy refuses to listen on a
+non-loopback address, and requests whose Host is not loopback, unless
+--allow-remote is given, since anyone who can reach it with the token can
+spend the providers' keys.`,
+	RunE: runProxy,
+}
+
+var proxyEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Point Claude Code at the proxy",
+	Run: func(cmd *cobra.Command, args []string) {
+		setProxyEnabled(true)
+	},
+}
+
+var proxyDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Point Claude Code directly at the current provider",
+	Run: func(cmd *cobra.Command, args []string) {
+		setProxyEnabled(false)
+	},
+}
+
+var proxyTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print the token clients send to the proxy as their API key",
+	RunE:  runProxyToken,
+}
+
+var proxyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show provider health reported by the running proxy",
+	Run:   runProxyStatus,
+}
+
+var (
+	proxyListen     string
+	proxyNoUsage    bool
+	proxyCapture    bool
+	proxyCaptureMax int
+	proxyRemote     bool
+)
+
+func init() {
+	proxyCmd.Flags().StringVarP(&proxyListen, "listen", "l", "", "Listen address (default: proxy.listen in config, or "+config.DefaultProxyListen+")")
+	proxyCmd.Flags().BoolVar(&proxyNoUsage, "no-usage", false, "Do not record token usage")
+	proxyCmd.Flags().BoolVar(&proxyCapture, "capture", false, "Record redacted request/response pairs for 'ccs replay'")
+	proxyCmd.Flags().IntVar(&proxyCaptureMax, "capture-max", capture.DefaultMax, "Number of captures to keep")
+	proxyCmd.Flags().BoolVar(&proxyRemote, "allow-remote", false, "Allow listening on a non-loopback address")
+	proxyCmd.AddCommand(proxyEnableCmd)
+	proxyCmd.AddCommand(proxyDisableCmd)
+	proxyCmd.AddCommand(proxyStatusCmd)
+	proxyCmd.AddCommand(proxyTokenCmd)
+}
+
+func runProxy(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return errReported
+	}
+
+	addr := cfg.Proxy.Addr()
+	if proxyListen != "" {
+		addr = proxyListen
+	}
+	if !config.IsLoopback(addr) {
+		if !proxyRemote {
+			color.Red("Refusing to listen on %s, which other machines can reach. Use a loopback address or --allow-remote", addr)
+			return errReported
+		}
+		color.Yellow("Listening on %s, anyone who can reach it with the proxy token can use your providers' keys", addr)
+	}
+	if _, err := cfg.EnsureProxyToken(); err != nil {
+		color.Red("Failed to create the proxy token: %v", err)
+		return errReported
+	}
+
+	server := &proxy.Server{
+		Logger:      log.New(os.Stderr, "", log.LstdFlags),
+		AllowRemote: proxyRemote,
+	}
+	if !proxyNoUsage {
+		if server.Usage, err = usage.Open(); err != nil {
+			color.Red("Failed to open usage store: %v", err)
+			return errReported
+		}
+	}
+
+	if proxyCapture {
+		if server.Capture, err = capture.NewRecorder(proxyCaptureMax); err != nil {
+			color.Red("Failed to set up capture: %v", err)
+			return errReported
+		}
+		server.Capture.OnSave = func(c *capture.Capture) {
+			server.Logger.Printf("captured %s (%s %s -> %s)", c.ID, c.Method, c.Path, c.Provider)
+		}
+		server.Capture.OnError = func(id string, err error) {
+			server.Logger.Printf("failed to write capture %s: %v", id, err)
+		}
+	}
+
+	color.Green("Proxy listening on http://%s", addr)
+	if proxyCapture {
+		color.Yellow("Capturing requests to %s", server.Capture.Dir)
+	}
+	if !cfg.Proxy.Enabled {
+		color.Yellow("Claude Code is not pointed at the proxy yet, run 'ccs proxy enable'")
+	}
+
+	if err := http.ListenAndServe(addr, server); err != nil {
+		color.Red("Proxy stopped: %v", err)
+		return errReported
+	}
+	return nil
+}
+
+// setProxyEnabled toggles the proxy and re-applies the current provider
+func setProxyEnabled(enabled bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	if _, err := cfg.EnsureProxyToken(); err != nil {
+		color.Red("Failed to create the proxy token: %v", err)
+		return
+	}
+	cfg.Proxy.Enabled = enabled
+	if current, err := cfg.GetCurrentProvider(); err == nil {
+		// The settings were written with the opposite proxy mode
+		previous := *cfg
+		previous.Proxy.Enabled = !enabled
+		if err := updateClaudeSettings(cfg, claudeProvider(&previous, current), current); err != nil {
+			color.Red("Failed to update Claude settings: %v", err)
+			return
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+
+	if enabled {
+		color.Green("Claude Code now uses the proxy at %s", cfg.Proxy.URL())
+		color.Yellow("Start it with 'ccs proxy' and restart running Claude Code sessions once")
+	} else {
+		color.Green("Claude Code now talks to providers directly")
+	}
+}
+
+func runProxyToken(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return errReported
+	}
+	token, err := cfg.EnsureProxyToken()
+	if err != nil {
+		color.Red("Failed to create the proxy token: %v", err)
+		return errReported
+	}
+	fmt.Println(token)
+	return nil
+}
+
+func runProxyStatus(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, cfg.Proxy.URL()+proxy.StatusPath, nil)
+	if err != nil {
+		color.Red("Invalid proxy address: %v", err)
+		return
+	}
+	req.Header.Set("x-api-key", cfg.Proxy.Token)
+	resp, err := client.Do(req)
+	if err != nil {
+		color.Red("Proxy is not running at %s", cfg.Proxy.URL())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		color.Red("Proxy at %s answered %s", cfg.Proxy.URL(), resp.Status)
+		return
+	}
+
+	var status proxy.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		color.Red("Unexpected status response: %v", err)
+		return
+	}
+
+	fmt.Printf("Proxy at %s, up %s\n", cfg.Proxy.URL(), time.Since(status.StartedAt).Round(time.Second))
+	if status.Current != "" {
+		fmt.Printf("Current provider: %s\n", status.Current)
+	}
+
+	for _, c := range cfg.Chains {
+		fmt.Printf("Chain %s: %s (%s/chain/%s)\n", c.Alias, strings.Join(c.Providers, " -> "), cfg.Proxy.URL(), c.Alias)
+	}
+	for _, rt := range cfg.Routers {
+		fmt.Printf("Router %s: %d rules, default %s (%s/route/%s)\n", rt.Alias, len(rt.Rules), rt.Default, cfg.Proxy.URL(), rt.Alias)
+	}
+
+	if len(status.Providers) == 0 {
+		color.Yellow("No requests proxied yet")
+		return
+	}
+
+	fmt.Println()
+	for _, ph := range status.Providers {
+		line := fmt.Sprintf("  %-12s %-9s %d requests, %d failures", ph.Alias, ph.State, ph.Requests, ph.Failures)
+		switch ph.State {
+		case proxy.StateOpen:
+			color.Red("%s, retry in %s", line, time.Until(ph.OpenUntil).Round(time.Second))
+		case proxy.StateHalfOpen:
+			color.Yellow("%s", line)
+		default:
+			color.Green("%s", line)
+		}
+		if ph.LastError != "" {
+			fmt.Printf("    Last error: %s (%s ago)\n", ph.LastError, time.Since(ph.LastFailure).Round(time.Second))
+		}
+	}
+}
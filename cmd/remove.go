@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
@@ -10,72 +12,216 @@ import (
 )
 
 var removeCmd = &cobra.Command{
-	Use:     "remove [alias]",
+	Use:     "remove [alias...]",
 	Aliases: []string{"rm"},
-	Short:   "Remove a provider (alias: rm)",
-	Run:     runRemove,
+	Short:   "Move providers to the trash (alias: rm)",
+	Long: `Move providers to the trash, from which 'ccs trash restore' brings them
+back. Providers are given by alias, by --tag, or picked interactively.
+
+Removing the current provider switches to another one, picked or given with
+--switch, or else removes its keys from settings.json so its token is no
+longer used. With --yes nothing is asked and the keys are removed unless
+--switch is given.
+
+Chains and routers using a removed provider are warned about and cannot use it
+until it is restored. With --yes such providers are only removed if --force
+is given too.`,
+	RunE: runRemove,
+}
+
+var (
+	removeTags   []string
+	removeYes    bool
+	removeSwitch string
+	removeForce  bool
+)
+
+func init() {
+	removeCmd.Flags().StringArrayVar(&removeTags, "tag", nil, "Remove the providers with this tag (repeatable, all must match)")
+	removeCmd.Flags().BoolVarP(&removeYes, "yes", "y", false, "Do not ask for confirmation")
+	removeCmd.Flags().BoolVar(&removeForce, "force", false, "With --yes, remove providers used by chains or routers")
+	removeCmd.Flags().StringVar(&removeSwitch, "switch", "", "Provider to switch to when removing the current one")
 }
 
-func runRemove(cmd *cobra.Command, args []string) {
+func runRemove(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
 		color.Red("Failed to load config: %v", err)
-		return
+		return errReported
 	}
 
 	if len(cfg.Providers) == 0 {
 		color.Yellow("No providers configured")
-		return
+		return nil
 	}
 
-	var alias string
-	if len(args) > 0 {
-		alias = args[0]
-	} else {
-		options := make([]string, len(cfg.Providers))
-		for i, p := range cfg.Providers {
-			options[i] = fmt.Sprintf("%s (%s)", p.Name, p.Alias)
+	aliases, ok := removeTargets(cfg, args)
+	if !ok {
+		if len(args) == 0 && len(removeTags) == 0 {
+			// The picker was cancelled
+			return nil
 		}
+		return errReported
+	}
+	if len(aliases) == 0 {
+		color.Yellow("No providers with tags %s", strings.Join(removeTags, ", "))
+		return nil
+	}
+
+	if warnReferences(cfg, aliases) && removeYes && !removeForce {
+		color.Red("Chains or routers use the providers, pass --force to remove them anyway")
+		return errReported
+	}
 
-		var selected int
-		prompt := &survey.Select{
-			Message: "Select provider:",
-			Options: options,
+	if !removeYes {
+		message := fmt.Sprintf("Move %d providers to the trash (%s)?", len(aliases), strings.Join(aliases, ", "))
+		if len(aliases) == 1 {
+			p, _ := cfg.GetProvider(aliases[0])
+			message = fmt.Sprintf("Move '%s' to the trash?", p.Name)
 		}
-		if err := survey.AskOne(prompt, &selected); err != nil {
-			return
+		var confirm bool
+		if err := survey.AskOne(&survey.Confirm{Message: message, Default: false}, &confirm); err != nil || !confirm {
+			return nil
 		}
-		alias = cfg.Providers[selected].Alias
 	}
 
-	provider, err := cfg.GetProvider(alias)
-	if err != nil {
-		color.Red("Provider '%s' not found", alias)
-		return
+	current, _ := cfg.GetCurrentProvider()
+	var applied *config.Provider
+	if current != nil && contains(aliases, current.Alias) {
+		c := current.Clone()
+		applied = &c
 	}
 
-	var confirm bool
-	confirmPrompt := &survey.Confirm{
-		Message: fmt.Sprintf("Delete '%s'?", provider.Name),
-		Default: false,
+	for _, alias := range aliases {
+		if err := cfg.TrashProvider(alias); err != nil {
+			color.Red("Provider '%s' not found", alias)
+			return errReported
+		}
 	}
-	if err := survey.AskOne(confirmPrompt, &confirm); err != nil {
-		return
+
+	var next *config.Provider
+	if applied != nil {
+		if next, ok = replacementProvider(cfg, applied); !ok {
+			if removeSwitch != "" {
+				return errReported
+			}
+			return nil
+		}
+		if next != nil {
+			cfg.CurrentProvider = next.Alias
+			now := time.Now()
+			next.LastUsed = &now
+		}
 	}
 
-	if !confirm {
-		return
+	// The config is saved first, so a failed save leaves settings.json
+	// matching the providers still on disk
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save: %v", err)
+		return errReported
 	}
 
-	if err := cfg.RemoveProvider(alias); err != nil {
-		color.Red("Provider '%s' not found", alias)
-		return
+	for _, alias := range aliases {
+		color.Green("Provider '%s' moved to the trash", alias)
 	}
+	if applied != nil {
+		if next != nil {
+			recordSwitch(next.Alias)
+		}
+		if err := updateClaudeSettings(cfg, applied, next); err != nil {
+			color.Red("Failed to update Claude settings: %v", err)
+			color.Yellow("settings.json may still use '%s', run 'ccs use' to fix it", applied.Alias)
+			return errReported
+		}
+		if next != nil {
+			color.Green("Switched to '%s'", next.Name)
+		} else {
+			color.Yellow("'%s' was the current provider, its settings were removed from settings.json", applied.Alias)
+		}
+	}
+	fmt.Println("Use 'ccs trash restore <alias>' to bring a provider back")
+	return nil
+}
 
-	if err := cfg.Save(); err != nil {
-		color.Red("Failed to save: %v", err)
-		return
+// warnReferences warns about the chains and routers using the providers
+// being removed, reporting whether there were any. They are left in place so
+// restoring a provider brings it back into them
+func warnReferences(cfg *config.Config, aliases []string) bool {
+	found := false
+	for _, alias := range aliases {
+		for _, ref := range cfg.ProviderReferences(alias) {
+			color.Yellow("Warning: %s uses '%s', the proxy cannot send requests to it there until it is restored", ref, alias)
+			found = true
+		}
+	}
+	return found
+}
+
+// removeTargets returns the aliases to remove: the arguments and the
+// providers with --tag, or a picked provider if there are neither. ok is
+// false if an alias is unknown or the picker was cancelled
+func removeTargets(cfg *config.Config, args []string) (aliases []string, ok bool) {
+	if len(args) == 0 && len(removeTags) == 0 {
+		picked, ok := pickProvider(cfg, "remove")
+		if !ok {
+			return nil, false
+		}
+		return []string{picked}, true
+	}
+
+	for _, arg := range args {
+		alias := resolveAlias(cfg, arg)
+		if _, err := cfg.GetProvider(alias); err != nil {
+			color.Red("Provider '%s' not found", arg)
+			return nil, false
+		}
+		if !contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(removeTags) > 0 {
+		for _, p := range withTags(cfg.Providers, removeTags) {
+			if !contains(aliases, p.Alias) {
+				aliases = append(aliases, p.Alias)
+			}
+		}
+	}
+	return aliases, true
+}
+
+// replacementProvider returns the provider to switch to in place of the
+// removed current one, nil to only remove its settings. ok is false if the
+// prompt was cancelled or --switch names an unknown provider
+func replacementProvider(cfg *config.Config, removed *config.Provider) (p *config.Provider, ok bool) {
+	if removeSwitch != "" {
+		p, err := cfg.GetProvider(resolveAlias(cfg, removeSwitch))
+		if err != nil {
+			color.Red("Provider '%s' not found", removeSwitch)
+			return nil, false
+		}
+		return p, true
+	}
+	if removeYes || len(cfg.Providers) == 0 {
+		return nil, true
 	}
 
-	color.Green("Provider '%s' removed", provider.Name)
+	providers := cfg.Ordered()
+	options := []string{"None, remove its settings from settings.json"}
+	for _, p := range providers {
+		options = append(options, fmt.Sprintf("%s (%s)", p.Name, p.Alias))
+	}
+
+	var selected int
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("'%s' is the current provider, switch to:", removed.Alias),
+		Options: options,
+	}
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return nil, false
+	}
+	if selected == 0 {
+		return nil, true
+	}
+	p, _ = cfg.GetProvider(providers[selected-1].Alias)
+	return p, true
 }
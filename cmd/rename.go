@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/health"
+	"github.com/katz/ccs/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a provider alias and update references to it",
+	Long: `Rename a provider alias. The current provider, chains, routers, switch
+history, usage records, health samples and the picker's remembered
+selections are updated, and settings.json is rewritten if the provider is
+current.
+
+--scan rewrites references to the old alias in the text files under a
+directory: arguments of ccs commands such as 'ccs use <old>', CCS_*
+variables and provider keys such as "provider": "<old>" or providers: [<old>].
+Other mentions are left alone; use --dry-run first to see what would change. --keep-old keeps the old alias working as a
+deprecated redirect that warns when used.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runRename,
+}
+
+var (
+	renameScan    []string
+	renameDryRun  bool
+	renameKeepOld bool
+)
+
+// maxScanSize skips files too large to be scripts or configs
+const maxScanSize = 1 << 20
+
+// skippedScanDirs are not descended into by --scan
+var skippedScanDirs = []string{".git", ".hg", ".svn", "node_modules", "vendor"}
+
+func init() {
+	renameCmd.Flags().StringArrayVar(&renameScan, "scan", nil, "Rewrite ccs references in the text files under a directory (repeatable)")
+	renameCmd.Flags().BoolVar(&renameDryRun, "dry-run", false, "Only show what would change")
+	renameCmd.Flags().BoolVar(&renameKeepOld, "keep-old", false, "Keep the old alias as a deprecated redirect")
+}
+
+func runRename(cmd *cobra.Command, args []string) {
+	oldAlias, newAlias := args[0], args[1]
+
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	p, err := cfg.GetProvider(oldAlias)
+	if err != nil {
+		color.Red("Provider '%s' not found", oldAlias)
+		return
+	}
+	original := p.Clone()
+	isCurrent := cfg.CurrentProvider == oldAlias
+
+	if err := cfg.RenameProvider(oldAlias, newAlias, renameKeepOld); err != nil {
+		switch {
+		case errors.Is(err, config.ErrInvalidAlias):
+			color.Red("Invalid alias '%s', use letters, digits, '.', '_' and '-'", newAlias)
+		case errors.Is(err, config.ErrProviderExists):
+			color.Red("Provider '%s' already exists", newAlias)
+		default:
+			color.Red("Failed to rename: %v", err)
+		}
+		return
+	}
+
+	if renameDryRun {
+		fmt.Printf("Would rename '%s' to '%s'\n", oldAlias, newAlias)
+	} else {
+		if err := cfg.Save(); err != nil {
+			color.Red("Failed to save config: %v", err)
+			return
+		}
+		if err := renameRecords(oldAlias, newAlias); err != nil {
+			color.Yellow("Warning: Failed to update history: %v", err)
+		}
+		if isCurrent {
+			renamed, _ := cfg.GetProvider(newAlias)
+			if err := updateClaudeSettings(cfg, &original, renamed); err != nil {
+				color.Yellow("Warning: Failed to update Claude settings: %v", err)
+			}
+		}
+		color.Green("Renamed '%s' to '%s'", oldAlias, newAlias)
+		if renameKeepOld {
+			fmt.Printf("'%s' still works but warns that it is deprecated\n", oldAlias)
+		}
+	}
+
+	for _, dir := range renameScan {
+		if err := rewriteReferences(dir, oldAlias, newAlias, renameDryRun); err != nil {
+			color.Red("Failed to scan %s: %v", dir, err)
+		}
+	}
+}
+
+// renameRecords points the switch history, usage records, health samples
+// and picker selections of a renamed provider at its new alias, so its
+// budget spend and rankings carry over
+func renameRecords(oldAlias, newAlias string) error {
+	renamePickerState(oldAlias, newAlias)
+
+	errs := []error{config.RenameHistory(oldAlias, newAlias)}
+	if store, err := usage.Open(); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, store.Rename(oldAlias, newAlias))
+	}
+	if store, err := health.Open(); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, store.Rename(oldAlias, newAlias))
+	}
+	return errors.Join(errs...)
+}
+
+// rewriteReferences replaces the references to an alias in the text files
+// under dir, printing each file changed
+func rewriteReferences(dir, oldAlias, newAlias string, dryRun bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && contains(skippedScanDirs, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > maxScanSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			color.Yellow("Warning: skipped %s: %v", path, err)
+			return nil
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil // Binary
+		}
+
+		text, n := replaceAlias(string(data), oldAlias, newAlias)
+		if n == 0 {
+			return nil
+		}
+		if dryRun {
+			fmt.Printf("  %s: %d references\n", path, n)
+			return nil
+		}
+		if err := os.WriteFile(path, []byte(text), info.Mode().Perm()); err != nil {
+			color.Red("  %s: %v", path, err)
+			return nil
+		}
+		fmt.Printf("  %s: %d references updated\n", path, n)
+		return nil
+	})
+}
+
+// ccsCommandPattern matches a ccs invocation up to the end of its shell
+// command or a comment. Group 1 is the arguments
+var ccsCommandPattern = regexp.MustCompile("(?m)(?:^|[^A-Za-z0-9._-])ccs[ \t]+([^;|&)`#\n]*)")
+
+// configRefPattern matches a CCS_* variable or provider key being set, such
+// as CCS_PROVIDER=or, "provider": "or" or providers: [or, ds]. Group 1 is
+// the value: a quoted string, a one-line list or a bare word
+var configRefPattern = regexp.MustCompile(`\b(?:CCS_[A-Z0-9_]*|current_provider|provider|providers)["']?[ \t]*[:=][ \t]*("[^"\n]*"|'[^'\n]*'|\[[^\]\n]*\]|[^\s,;#}\]]+)`)
+
+// referenceRegions returns the sorted, non-overlapping [start, end) spans
+// of text where an alias is a reference to a provider
+func referenceRegions(text string) [][2]int {
+	var regions [][2]int
+	for _, re := range []*regexp.Regexp{ccsCommandPattern, configRefPattern} {
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			regions = append(regions, [2]int{m[2], m[3]})
+		}
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i][0] < regions[j][0] })
+
+	var merged [][2]int
+	for _, r := range regions {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			if r[1] > merged[n-1][1] {
+				merged[n-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// replaceAlias replaces the references to an alias, occurrences not embedded
+// in a longer alias within ccs commands and provider settings (see
+// referenceRegions), returning the new text and the number replaced. Prose
+// mentioning a short alias such as "or" is left alone
+func replaceAlias(text, oldAlias, newAlias string) (string, int) {
+	var b strings.Builder
+	n, start := 0, 0
+	for _, r := range referenceRegions(text) {
+		for from := r[0]; ; {
+			i := strings.Index(text[from:r[1]], oldAlias)
+			if i < 0 {
+				break
+			}
+			i += from
+			end := i + len(oldAlias)
+			if (i == 0 || !isAliasByte(text[i-1])) && (end == len(text) || !isAliasByte(text[end])) {
+				b.WriteString(text[start:i])
+				b.WriteString(newAlias)
+				start = end
+				n++
+			}
+			from = end
+		}
+	}
+	b.WriteString(text[start:])
+	return b.String(), n
+}
+
+// isAliasByte reports whether a byte can be part of an alias
+func isAliasByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '.' || c == '_' || c == '-'
+}
+
+// resolveAlias follows a deprecated alias left by 'ccs rename --keep-old',
+// warning when it does
+func resolveAlias(cfg *config.Config, alias string) string {
+	resolved, ok := cfg.ResolveRedirect(alias)
+	if !ok {
+		return alias
+	}
+	color.Yellow("Warning: '%s' was renamed to '%s', the old alias is deprecated", alias, resolved)
+	return resolved
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import "testing"
+
+func TestReplaceAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		n    int
+	}{
+		{"ccs command", "ccs use or\n", "ccs use openrouter\n", 1},
+		{"command alias", "ccs u or", "ccs u openrouter", 1},
+		{"several arguments", "ccs rm ds or --yes", "ccs rm ds openrouter --yes", 1},
+		{"path to ccs", "/usr/local/bin/ccs use or", "/usr/local/bin/ccs use openrouter", 1},
+		{"command substitution", `echo "$(ccs use or)" or not`, `echo "$(ccs use openrouter)" or not`, 1},
+		{"prose untouched", "Use this or that provider.", "Use this or that provider.", 0},
+		{"after the command ends", "ccs use or && echo this or that", "ccs use openrouter && echo this or that", 1},
+		{"comment untouched", "ccs use or # or switch later", "ccs use openrouter # or switch later", 1},
+		{"longer alias untouched", "ccs use or-opus; ccs use orx", "ccs use or-opus; ccs use orx", 0},
+		{"not ccs", "accs use or; ccs-helper use or", "accs use or; ccs-helper use or", 0},
+		{"env variable", "export CCS_PROVIDER=or", "export CCS_PROVIDER=openrouter", 1},
+		{"json key", `{"provider": "or", "note": "this or that"}`, `{"provider": "openrouter", "note": "this or that"}`, 1},
+		{"yaml list", "providers: [or, ds]\ntitle: this or that", "providers: [openrouter, ds]\ntitle: this or that", 1},
+		{"current provider", `"current_provider": "or"`, `"current_provider": "openrouter"`, 1},
+		{"multiple lines", "# switch or not\nccs use or\nccs test or\n", "# switch or not\nccs use openrouter\nccs test openrouter\n", 2},
+		{"no references", "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := replaceAlias(tt.text, "or", "openrouter")
+			if got != tt.want || n != tt.n {
+				t.Errorf("replaceAlias(%q) = %q, %d, want %q, %d", tt.text, got, n, tt.want, tt.n)
+			}
+		})
+	}
+}
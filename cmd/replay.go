@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/capture"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [capture-id]",
+	Short: "Resend a request captured by 'ccs proxy --capture'",
+	Long: `Resend a request captured by 'ccs proxy --capture' to another provider
+and print its response next to the captured one. A unique prefix of the
+capture ID is enough. Without an ID the stored captures are listed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReplay,
+}
+
+var (
+	replayTo        string
+	replayKeepModel bool
+	replayNoBody    bool
+)
+
+func init() {
+	replayCmd.Flags().StringVar(&replayTo, "to", "", "Provider alias to replay against (default: current provider)")
+	replayCmd.Flags().BoolVar(&replayKeepModel, "keep-model", false, "Send the captured model name instead of mapping it onto the provider's models")
+	replayCmd.Flags().BoolVar(&replayNoBody, "no-body", false, "Do not print the response body")
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		listCaptures()
+		return nil
+	}
+
+	c, err := capture.Load(args[0])
+	if err != nil {
+		if errors.Is(err, capture.ErrNotFound) {
+			color.Red("Capture '%s' not found", args[0])
+		} else {
+			color.Red("Failed to load capture: %v", err)
+		}
+		return errReported
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return errReported
+	}
+
+	var p *config.Provider
+	if replayTo != "" {
+		p, err = cfg.GetProvider(resolveAlias(cfg, replayTo))
+		if err != nil {
+			color.Red("Provider '%s' not found", replayTo)
+			return errReported
+		}
+	} else {
+		p, err = cfg.GetCurrentProvider()
+		if err != nil {
+			color.Yellow("No current provider, pass --to <alias>")
+			return nil
+		}
+	}
+
+	fmt.Printf("Captured: %s %s %s -> %s, %s\n", c.ID, c.Method, c.Path, c.Provider, captureOutcome(c.Status, c.Error, c.DurationMS))
+
+	// Credentials were redacted when captured; the provider's key is injected instead
+	header := make(http.Header)
+	for name, values := range c.RequestHeaders {
+		if config.IsSecretKey(name) {
+			continue
+		}
+		header[name] = values
+	}
+
+	server := &proxy.Server{}
+	start := time.Now()
+	resp, err := server.Replay(context.Background(), c.Method, c.Path, header, capture.Body(c.RequestBody), p, !replayKeepModel)
+	if err != nil {
+		color.Red("Replayed: -> %s, %s", p.Alias, captureOutcome(0, err.Error(), time.Since(start).Milliseconds()))
+		return errReported
+	}
+	defer resp.Body.Close()
+
+	out := io.Discard
+	if !replayNoBody {
+		out = os.Stdout
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		color.Red("Failed to read response: %v", err)
+	}
+	if !replayNoBody {
+		fmt.Println()
+	}
+
+	line := fmt.Sprintf("Replayed: -> %s, %s", p.Alias, captureOutcome(resp.StatusCode, "", time.Since(start).Milliseconds()))
+	if resp.StatusCode >= 400 {
+		color.Red("%s", line)
+		return errReported
+	}
+	color.Green("%s", line)
+	return nil
+}
+
+// listCaptures prints the stored captures, newest last
+func listCaptures() {
+	list, err := capture.List()
+	if err != nil {
+		color.Red("Failed to list captures: %v", err)
+		return
+	}
+	if len(list) == 0 {
+		color.Yellow("No captures. Run 'ccs proxy --capture' to record requests.")
+		return
+	}
+
+	for _, c := range list {
+		line := fmt.Sprintf("%-23s %s  %-12s %s %s  %s",
+			c.ID, c.Time.Local().Format("2006-01-02 15:04:05"), c.Provider, c.Method, c.Path,
+			captureOutcome(c.Status, c.Error, c.DurationMS))
+		if c.Error != "" || c.Status >= 400 {
+			color.Red("%s", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+// captureOutcome summarizes a status or error with its duration
+func captureOutcome(status int, errMsg string, durationMS int64) string {
+	if errMsg != "" {
+		return fmt.Sprintf("error: %s (%dms)", strings.TrimSpace(errMsg), durationMS)
+	}
+	return fmt.Sprintf("HTTP %d (%dms)", status, durationMS)
+}
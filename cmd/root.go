@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -22,8 +23,15 @@ var rootCmd = &cobra.Command{
 	Use:   "ccs",
 	Short: name,
 	Long:  `Claude Code Switcher - Manage multiple Claude Code API providers`,
+	// Execute reports errors
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
+// errReported is returned by commands that already printed why they failed,
+// so Execute only sets the exit status
+var errReported = errors.New("command failed")
+
 func init() {
 	rootCmd.Version = version
 	rootCmd.SetVersionTemplate(fmt.Sprintf(`%s version %s
@@ -64,6 +72,22 @@ OS/Arch:    %s/%s
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(useCmd)
 	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(presetsCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(modelsCmd)
+	rootCmd.AddCommand(proxyCmd)
+	rootCmd.AddCommand(usageCmd)
+	rootCmd.AddCommand(budgetCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(cloneCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(moveCmd)
+	rootCmd.AddCommand(favCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(trashCmd)
 }
 
 func contains(slice []string, item string) bool {
@@ -75,10 +99,16 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-// Execute runs the root command
+// Execute runs the root command, exiting with status 1 if it fails. Errors
+// other than errReported come from parsing commands, arguments and flags
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	c, err := rootCmd.ExecuteC()
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, errReported) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", c.CommandPath())
 	}
+	os.Exit(1)
 }
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/katz/ccs/internal/display"
+	"golang.org/x/term"
+)
+
+// minColumnWidth is the narrowest a shrinkable column gets
+const minColumnWidth = 8
+
+// terminalWidth returns the width of the terminal on stdout, or 0 if stdout
+// is not a terminal, in which case tables are not truncated
+func terminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// renderTable aligns rows under a header and returns the lines, measuring
+// cells in terminal columns so CJK text lines up. If maxWidth
+// is positive, the shrinkable columns (by index) are narrowed, widest first,
+// until the table fits, and cells cut to fit end in "..."
+func renderTable(header []string, rows [][]string, shrinkable []int, maxWidth int) []string {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if n := display.Width(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	if maxWidth > 0 {
+		total := func() int {
+			sum := 2 * (len(widths) - 1)
+			for _, w := range widths {
+				sum += w
+			}
+			return sum
+		}
+		for total() > maxWidth {
+			widest := -1
+			for _, i := range shrinkable {
+				if widths[i] > minColumnWidth && (widest < 0 || widths[i] > widths[widest]) {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			widths[widest]--
+		}
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for _, row := range append([][]string{header}, rows...) {
+		var b strings.Builder
+		for i, cell := range row {
+			cell = truncate(cell, widths[i])
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-display.Width(cell)+2))
+			}
+		}
+		lines = append(lines, strings.TrimRight(b.String(), " "))
+	}
+	return lines
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import "testing"
+
+func TestRenderTableWideCharacters(t *testing.T) {
+	header := []string{"ALIAS", "NAME"}
+	rows := [][]string{
+		{"ds", "深度求索"},
+		{"or", "OpenRouter"},
+	}
+	want := []string{
+		"ALIAS  NAME",
+		"ds     深度求索",
+		"or     OpenRouter",
+	}
+	lines := renderTable(header, rows, nil, 0)
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+
+	// Narrowed to fit, the wide name is cut on a column boundary
+	lines = renderTable([]string{"NAME", "HOST"}, [][]string{{"智谱清言开放平台", "open.bigmodel.cn"}}, []int{0}, 28)
+	if want := "智谱清...   open.bigmodel.cn"; lines[1] != want {
+		t.Errorf("narrowed row = %q, want %q", lines[1], want)
+	}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "List tags or tag providers",
+	Run:   runTagList,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <alias> <tag>...",
+	Short: "Add tags to a provider",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateTags(args[0], args[1:], true)
+	},
+}
+
+var tagRmCmd = &cobra.Command{
+	Use:     "rm <alias> <tag>...",
+	Aliases: []string{"remove"},
+	Short:   "Remove tags from a provider",
+	Args:    cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateTags(args[0], args[1:], false)
+	},
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRmCmd)
+}
+
+// runTagList prints every tag with the providers that have it
+func runTagList(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	tags := cfg.Tags()
+	if len(tags) == 0 {
+		color.Yellow("No tags. Use 'ccs tag add <alias> <tag>' to add one.")
+		return
+	}
+
+	for _, tag := range tags {
+		var aliases []string
+		for _, p := range cfg.Providers {
+			if p.HasTag(tag) {
+				aliases = append(aliases, p.Alias)
+			}
+		}
+		fmt.Printf("%s (%d): %s\n", tag, len(aliases), strings.Join(aliases, ", "))
+	}
+}
+
+// updateTags adds or removes tags on a provider
+func updateTags(alias string, tags []string, add bool) {
+	for _, tag := range tags {
+		if err := config.ValidateTag(tag); err != nil {
+			color.Red("Invalid tag '%s': tags must be non-empty without spaces or commas", tag)
+			return
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	alias = resolveAlias(cfg, alias)
+	p, err := cfg.GetProvider(alias)
+	if err != nil {
+		color.Red("Provider '%s' not found", alias)
+		return
+	}
+
+	if add {
+		p.AddTags(tags...)
+	} else {
+		p.RemoveTags(tags...)
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+
+	if len(p.Tags) == 0 {
+		color.Green("'%s' has no tags", p.Alias)
+	} else {
+		color.Green("'%s' tags: %s", p.Alias, strings.Join(p.Tags, ", "))
+	}
+}
+
+// withTags returns the providers that have every one of tags
+func withTags(providers []config.Provider, tags []string) []config.Provider {
+	if len(tags) == 0 {
+		return providers
+	}
+
+	var matched []config.Provider
+	for _, p := range providers {
+		ok := true
+		for _, tag := range tags {
+			if !p.HasTag(tag) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// tagGroup is the providers sharing one tag
+type tagGroup struct {
+	Tag       string
+	Providers []config.Provider
+}
+
+// untaggedGroup names the group of providers without tags
+const untaggedGroup = "(untagged)"
+
+// groupByTag groups providers by tag, in tag order with untagged providers
+// last. A provider with several tags appears in each of their groups
+func groupByTag(providers []config.Provider) []tagGroup {
+	cfg := config.Config{Providers: providers}
+
+	var groups []tagGroup
+	for _, tag := range cfg.Tags() {
+		groups = append(groups, tagGroup{Tag: tag, Providers: withTags(providers, []string{tag})})
+	}
+
+	var untagged []config.Provider
+	for _, p := range providers {
+		if len(p.Tags) == 0 {
+			untagged = append(untagged, p)
+		}
+	}
+	if len(untagged) > 0 {
+		groups = append(groups, tagGroup{Tag: untaggedGroup, Providers: untagged})
+	}
+	return groups
+}
+
+// selectByTag narrows the providers for an interactive picker by asking
+// for a tag first, if any provider has tags. The providers are in display
+// order. ok is false if the prompt was cancelled
+func selectByTag(cfg *config.Config) (providers []config.Provider, ok bool) {
+	providers = cfg.Ordered()
+	tags := cfg.Tags()
+	if len(tags) == 0 {
+		return providers, true
+	}
+
+	options := []string{fmt.Sprintf("All providers (%d)", len(providers))}
+	for _, tag := range tags {
+		options = append(options, fmt.Sprintf("%s (%d)", tag, len(withTags(providers, []string{tag}))))
+	}
+
+	var selected int
+	prompt := &survey.Select{
+		Message: "Select tag:",
+		Options: options,
+	}
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return nil, false
+	}
+	if selected == 0 {
+		return providers, true
+	}
+	return withTags(providers, []string{tags[selected-1]}), true
+}
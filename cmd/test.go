@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/probe"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:     "test [alias]",
+	Aliases: []string{"t"},
+	Short:   "Test provider connectivity, auth and models (alias: t)",
+	RunE:    runTest,
+}
+
+var (
+	testAll     bool
+	testJSON    bool
+	testTimeout time.Duration
+)
+
+func init() {
+	testCmd.Flags().BoolVarP(&testAll, "all", "a", false, "Test all providers")
+	testCmd.Flags().BoolVar(&testJSON, "json", false, "Print results as JSON")
+	testCmd.Flags().DurationVar(&testTimeout, "timeout", 0, "Per-request timeout (default: provider timeout)")
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return errReported
+	}
+
+	if len(cfg.Providers) == 0 {
+		color.Yellow("No providers configured")
+		return nil
+	}
+
+	var providers []config.Provider
+	switch {
+	case testAll:
+		providers = cfg.Providers
+	case len(args) > 0:
+		p, err := cfg.GetProvider(resolveAlias(cfg, args[0]))
+		if err != nil {
+			color.Red("Provider '%s' not found", args[0])
+			return errReported
+		}
+		providers = []config.Provider{*p}
+	default:
+		p, err := cfg.GetCurrentProvider()
+		if err != nil {
+			color.Yellow("No current provider, pass an alias or --all")
+			return nil
+		}
+		providers = []config.Provider{*p}
+	}
+
+	prober := &probe.Prober{Timeout: testTimeout}
+	results := prober.ProbeAll(context.Background(), providers)
+
+	if testJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			color.Red("Failed to encode results: %v", err)
+			return errReported
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, r := range results {
+			printTestResult(r)
+		}
+	}
+
+	for _, r := range results {
+		if !r.OK {
+			return errReported
+		}
+	}
+	return nil
+}
+
+func printTestResult(r probe.Result) {
+	header := fmt.Sprintf("%s (%s)", r.Name, r.Alias)
+	switch {
+	case r.OK:
+		color.Green("%s: OK (HTTP %d, %dms)", header, r.Status, r.LatencyMS)
+	case r.AuthFailed:
+		color.Red("%s: auth failed", header)
+	case r.Error != "":
+		color.Red("%s: unreachable", header)
+	default:
+		color.Yellow("%s: model errors (HTTP %d, %dms)", header, r.Status, r.LatencyMS)
+	}
+
+	if r.Error != "" {
+		fmt.Printf("  Error: %s\n", r.Error)
+	}
+
+	for _, m := range r.Models {
+		roles := strings.Join(m.Roles, ", ")
+		if m.Accepted {
+			color.Green("  %s [%s]: accepted (%dms)", m.Model, roles, m.LatencyMS)
+		} else {
+			color.Red("  %s [%s]: %s", m.Model, roles, m.Error)
+		}
+	}
+}
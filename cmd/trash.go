@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or delete removed providers",
+	Run:   runTrashList,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List removed providers",
+	Args:    cobra.NoArgs,
+	Run:     runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <alias>...",
+	Short: "Bring removed providers back",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runTrashRestore,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty [alias...]",
+	Short: "Permanently delete removed providers, all of them if no alias is given",
+	Run:   runTrashEmpty,
+}
+
+var trashEmptyYes bool
+
+func init() {
+	trashEmptyCmd.Flags().BoolVarP(&trashEmptyYes, "yes", "y", false, "Do not ask for confirmation")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+}
+
+// runTrashList prints the removed providers, most recently removed first
+func runTrashList(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	if len(cfg.Trash) == 0 {
+		color.Yellow("The trash is empty")
+		return
+	}
+
+	now := time.Now()
+	header := []string{"ALIAS", "NAME", "BASE URL", "REMOVED"}
+	rows := make([][]string, 0, len(cfg.Trash))
+	for i := len(cfg.Trash) - 1; i >= 0; i-- {
+		t := cfg.Trash[i]
+		rows = append(rows, []string{t.Provider.Alias, t.Provider.Name, t.Provider.BaseURL, formatAgo(now.Sub(t.RemovedAt))})
+	}
+	for _, line := range renderTable(header, rows, []int{1, 2}, terminalWidth()) {
+		fmt.Println(line)
+	}
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	var restored []string
+	for _, alias := range args {
+		if _, err := cfg.RestoreProvider(alias); err != nil {
+			switch {
+			case errors.Is(err, config.ErrNotInTrash):
+				color.Red("Provider '%s' is not in the trash", alias)
+			case errors.Is(err, config.ErrProviderExists):
+				color.Red("Provider '%s' already exists, rename it before restoring", alias)
+			default:
+				color.Red("Failed to restore '%s': %v", alias, err)
+			}
+			continue
+		}
+		restored = append(restored, alias)
+	}
+	if len(restored) == 0 {
+		return
+	}
+
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+	for _, alias := range restored {
+		color.Green("Provider '%s' restored", alias)
+	}
+}
+
+func runTrashEmpty(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	if len(cfg.Trash) == 0 {
+		color.Yellow("The trash is empty")
+		return
+	}
+
+	if !trashEmptyYes {
+		message := fmt.Sprintf("Permanently delete the %d providers in the trash?", len(cfg.Trash))
+		if len(args) > 0 {
+			message = fmt.Sprintf("Permanently delete %s from the trash?", joinQuoted(args))
+		}
+		var confirm bool
+		if err := survey.AskOne(&survey.Confirm{Message: message, Default: false}, &confirm); err != nil || !confirm {
+			return
+		}
+	}
+
+	n := cfg.EmptyTrash(args...)
+	if n == 0 {
+		color.Yellow("No such providers in the trash")
+		return
+	}
+	if err := cfg.Save(); err != nil {
+		color.Red("Failed to save config: %v", err)
+		return
+	}
+	color.Green("Deleted %d providers from the trash", n)
+}
+
+// joinQuoted joins aliases as 'a', 'b'
+func joinQuoted(aliases []string) string {
+	quoted := make([]string, len(aliases))
+	for i, alias := range aliases {
+		quoted[i] = "'" + alias + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
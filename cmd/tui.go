@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/tui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Manage providers in a full-screen terminal UI",
+	Long: `Manage providers in a full-screen terminal UI.
+
+Keys: enter switch, e edit, a add, c clone, d delete, t test connectivity,
+v view the settings.json env, q quit. In the edit form enter edits a field,
+s saves and esc discards.`,
+	Run: runTUI,
+}
+
+func init() {
+	tuiCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show API keys and secret env values")
+}
+
+func runTUI(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	app := &tui.App{
+		Config:      cfg,
+		Out:         color.Output,
+		Apply:       applyFromTUI,
+		Rename:      renameRecords,
+		ShowSecrets: showSecrets,
+		Size: func() (int, int) {
+			width, height, err := term.GetSize(int(os.Stdout.Fd()))
+			if err != nil {
+				return 0, 0
+			}
+			return width, height
+		},
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		color.Red("ccs tui needs a terminal")
+		return
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		color.Red("Failed to set up the terminal: %v", err)
+		return
+	}
+	app.In = tui.NewTerminalInput(os.Stdin)
+	err = app.Run()
+	term.Restore(fd, state)
+	if err != nil {
+		color.Red("%v", err)
+	}
+}
+
+// applyFromTUI writes a provider to settings.json, returning the warnings
+// 'ccs use' would print
+func applyFromTUI(cfg *config.Config, applied, p *config.Provider) ([]string, error) {
+	modified, err := applyClaudeSettings(cfg, applied, p)
+	warnings := make([]string, len(modified))
+	for i, key := range modified {
+		warnings[i] = fmt.Sprintf("%s was modified outside ccs", key)
+	}
+	return warnings, err
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show token usage recorded by the proxy",
+	Run:   runUsage,
+}
+
+var (
+	usageSince string
+	usageBy    string
+	usageJSON  bool
+)
+
+func init() {
+	usageCmd.Flags().StringVar(&usageSince, "since", "7d", "Period to report, e.g. 24h, 7d, 2w or 2025-01-31")
+	usageCmd.Flags().StringVar(&usageBy, "by", usage.ByProvider, "Group by provider, model or day")
+	usageCmd.Flags().BoolVar(&usageJSON, "json", false, "Print the report as JSON")
+}
+
+func runUsage(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		color.Red("Failed to load config: %v", err)
+		return
+	}
+
+	since, err := usage.ParseSince(usageSince, time.Now())
+	if err != nil {
+		color.Red("%v", err)
+		return
+	}
+
+	store, err := usage.Open()
+	if err != nil {
+		color.Red("Failed to open usage store: %v", err)
+		return
+	}
+
+	records, err := store.Load(since)
+	if err != nil {
+		color.Red("Failed to load usage: %v", err)
+		return
+	}
+
+	summaries, err := usage.Summarize(records, usageBy, cfg.Prices)
+	if err != nil {
+		color.Red("%v", err)
+		return
+	}
+
+	if usageJSON {
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			color.Red("Failed to encode usage: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(summaries) == 0 {
+		color.Yellow("No usage recorded since %s", since.Format("2006-01-02 15:04"))
+		return
+	}
+
+	width := len(usageBy)
+	for _, s := range summaries {
+		if len(s.Key) > width {
+			width = len(s.Key)
+		}
+	}
+
+	priced := len(cfg.Prices) > 0
+	header := fmt.Sprintf("%-*s  %8s  %12s  %12s  %12s  %12s", width, usageBy, "requests", "input", "output", "cache write", "cache read")
+	if priced {
+		header += fmt.Sprintf("  %10s", "cost")
+	}
+	fmt.Println(header)
+
+	var total usage.Summary
+	for _, s := range summaries {
+		printUsageRow(s, width, priced)
+		total.Requests += s.Requests
+		total.InputTokens += s.InputTokens
+		total.OutputTokens += s.OutputTokens
+		total.CacheWrite += s.CacheWrite
+		total.CacheRead += s.CacheRead
+		total.Cost += s.Cost
+		total.Unpriced += s.Unpriced
+	}
+
+	if len(summaries) > 1 {
+		total.Key = "total"
+		printUsageRow(total, width, priced)
+	}
+
+	if priced && total.Unpriced > 0 {
+		color.Yellow("%d requests used models without a price and are excluded from the cost", total.Unpriced)
+	}
+}
+
+func printUsageRow(s usage.Summary, width int, priced bool) {
+	row := fmt.Sprintf("%-*s  %8d  %12d  %12d  %12d  %12d", width, s.Key, s.Requests, s.InputTokens, s.OutputTokens, s.CacheWrite, s.CacheRead)
+	if priced {
+		cost := "-"
+		if s.Unpriced < s.Requests {
+			cost = fmt.Sprintf("$%.4f", s.Cost)
+		}
+		row += fmt.Sprintf("  %10s", cost)
+	}
+	fmt.Println(row)
+}
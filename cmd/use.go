@@ -1,83 +1,178 @@
 package cmd
 
 import (
-	"fmt"
+	"net/url"
+	"time"
 
-	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
 	"github.com/katz/ccs/internal/claude"
 	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/proxy"
 	"github.com/spf13/cobra"
 )
 
 var useCmd = &cobra.Command{
-	Use:     "use [alias]",
+	Use:     "use [alias | - | @{N}]",
 	Aliases: []string{"u"},
 	Short:   "Switch to a provider (alias: u)",
-	Run:     runUse,
+	Long: `Switch to a provider.
+
+'-' switches back to the previous provider, like 'cd -', and @{N} to the
+Nth switch before the latest one in 'ccs history'.
+
+With --best, all providers (or the aliases given) are probed concurrently
+and the reachable one with the best latency and error history is chosen.`,
+	RunE: runUse,
+}
+
+var (
+	useBest        bool
+	useBestTimeout time.Duration
+	useBestFull    bool
+)
+
+func init() {
+	useCmd.Flags().BoolVar(&useBest, "best", false, "Probe the providers and switch to the best one")
+	useCmd.Flags().DurationVar(&useBestTimeout, "timeout", 5*time.Second, "Timeout for each probe request with --best")
+	useCmd.Flags().BoolVar(&useBestFull, "full", false, "With --best, also probe each configured model")
 }
 
-func runUse(cmd *cobra.Command, args []string) {
+func runUse(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
 		color.Red("Failed to load config: %v", err)
-		return
+		return errReported
 	}
 
 	if len(cfg.Providers) == 0 {
 		color.Yellow("No providers configured")
-		return
+		return nil
 	}
 
 	var alias string
-	if len(args) > 0 {
-		alias = args[0]
-	} else {
-		options := make([]string, len(cfg.Providers))
-		for i, p := range cfg.Providers {
-			marker := "  "
-			if p.Alias == cfg.CurrentProvider {
-				marker = "* "
-			}
-			options[i] = fmt.Sprintf("%s%s (%s)", marker, p.Name, p.Alias)
+	if useBest {
+		if alias = pickBest(cfg, args); alias == "" {
+			return errReported
 		}
-
-		var selected int
-		prompt := &survey.Select{
-			Message: "Select provider:",
-			Options: options,
+	} else if len(args) > 0 && isHistoryRef(args[0]) {
+		if alias, err = resolveHistoryRef(args[0]); err != nil {
+			color.Red("%v", err)
+			return errReported
 		}
-		if err := survey.AskOne(prompt, &selected); err != nil {
-			return
+	} else if len(args) > 0 {
+		alias = args[0]
+	} else {
+		picked, ok := pickProvider(cfg, "use")
+		if !ok {
+			return nil
 		}
-		alias = cfg.Providers[selected].Alias
+		alias = picked
 	}
 
+	alias = resolveAlias(cfg, alias)
 	provider, err := cfg.GetProvider(alias)
 	if err != nil {
 		color.Red("Provider '%s' not found", alias)
-		return
-	}
-
-	settings, err := claude.LoadSettings()
-	if err != nil {
-		color.Red("Failed to load Claude settings: %v", err)
-		return
+		return errReported
 	}
 
-	settings.ClearProviderSettings()
-	settings.ApplyProvider(provider)
-
-	if err := settings.Save(); err != nil {
+	current, _ := cfg.GetCurrentProvider()
+	if err := updateClaudeSettings(cfg, current, provider); err != nil {
 		color.Red("Failed to update Claude settings: %v", err)
-		return
+		return errReported
 	}
 
 	cfg.CurrentProvider = alias
+	now := time.Now()
+	provider.LastUsed = &now
 	if err := cfg.Save(); err != nil {
 		color.Red("Failed to save config: %v", err)
-		return
+		return errReported
 	}
+	recordSwitch(alias)
 
 	color.Green("Switched to '%s'", provider.Name)
+	if provider.IsOpenAI() {
+		color.Yellow("'%s' uses the OpenAI API, keep 'ccs proxy' running to translate requests", provider.Alias)
+	}
+	warnBudget(cfg, provider)
+	return nil
+}
+
+// updateClaudeSettings replaces the previously applied provider in
+// settings.json with p. applied may be nil, and a nil p only removes the
+// applied provider's settings
+func updateClaudeSettings(cfg *config.Config, applied, p *config.Provider) error {
+	modified, err := applyClaudeSettings(cfg, applied, p)
+	warnModifiedKeys(modified)
+	return err
+}
+
+// applyClaudeSettings is updateClaudeSettings without printing, returning
+// the managed env keys that were modified outside ccs
+func applyClaudeSettings(cfg *config.Config, applied, p *config.Provider) ([]string, error) {
+	settings, err := loadClaudeSettings(applied)
+	if err != nil {
+		return nil, err
+	}
+	modified := settings.ClearProviderSettings()
+	if p != nil {
+		if _, err := cfg.EnsureProxyToken(); err != nil {
+			return modified, err
+		}
+		settings.ApplyProvider(claudeProvider(cfg, p))
+	}
+	return modified, settings.Save()
+}
+
+// claudeProvider returns the provider as Claude Code should see it. With the
+// proxy enabled Claude Code talks to the proxy using the proxy token and
+// default model names, which the proxy maps onto the selected provider.
+// OpenAI-format providers always go through the proxy's translating
+// endpoint for that provider, keeping their model names, and providers whose
+// base URL is a proxy endpoint such as a chain get the proxy token
+func claudeProvider(cfg *config.Config, p *config.Provider) *config.Provider {
+	if !cfg.Proxy.Enabled {
+		proxied := *p
+		switch {
+		case p.IsOpenAI():
+			proxied.BaseURL = cfg.Proxy.URL() + proxy.ProviderPrefix + url.PathEscape(p.Alias)
+		case !cfg.Proxy.IsProxyURL(p.BaseURL):
+			return p
+		}
+		proxied.APIKey = cfg.Proxy.Token
+		return &proxied
+	}
+
+	proxied := *p
+	proxied.BaseURL = cfg.Proxy.URL()
+	proxied.APIKey = cfg.Proxy.Token
+	proxied.Model = ""
+	proxied.SmallModel = ""
+	proxied.SonnetModel = ""
+	proxied.OpusModel = ""
+	proxied.HaikuModel = ""
+	return &proxied
+}
+
+// loadClaudeSettings loads settings.json. Settings written by an older ccs
+// without a state file are adopted from the applied provider, so ccs keeps
+// owning the keys it wrote back then. applied may be nil
+func loadClaudeSettings(applied *config.Provider) (*claude.Settings, error) {
+	settings, err := claude.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.HasState() && applied != nil {
+		settings.AdoptProvider(applied)
+	}
+	return settings, nil
+}
+
+// warnModifiedKeys warns about managed env keys changed outside ccs
+func warnModifiedKeys(keys []string) {
+	for _, key := range keys {
+		color.Yellow("Warning: %s was modified outside ccs", key)
+	}
 }
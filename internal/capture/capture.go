@@ -0,0 +1,203 @@
+package capture
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// DefaultMax is how many captures are kept by default
+const DefaultMax = 200
+
+// maxBody bounds how much of each body is captured
+const maxBody = 4 << 20
+
+// ErrNotFound is returned when no capture matches an ID
+var ErrNotFound = errors.New("capture not found")
+
+// ErrAmbiguous is returned when an ID prefix matches several captures
+var ErrAmbiguous = errors.New("capture ID is ambiguous")
+
+// Capture is one recorded request/response pair. Bodies that are valid JSON
+// are stored as JSON, other bodies (such as SSE streams) as strings
+type Capture struct {
+	ID              string          `json:"id"`
+	Time            time.Time       `json:"time"`
+	Provider        string          `json:"provider"` // Provider alias
+	Method          string          `json:"method"`
+	Path            string          `json:"path"` // Messages API path, before any translation
+	URL             string          `json:"url"`  // Upstream URL
+	RequestHeaders  http.Header     `json:"request_headers"`
+	RequestBody     json.RawMessage `json:"request_body,omitempty"`
+	Status          int             `json:"status,omitempty"`
+	ResponseHeaders http.Header     `json:"response_headers,omitempty"`
+	ResponseBody    json.RawMessage `json:"response_body,omitempty"`
+	Truncated       bool            `json:"truncated,omitempty"` // A body exceeded the capture limit
+	HeaderMS        int64           `json:"header_ms"`           // Time to response headers
+	DurationMS      int64           `json:"duration_ms"`         // Time to the end of the response body
+	Error           string          `json:"error,omitempty"`     // Transport error, if any
+}
+
+// Body returns a captured body as sent on the wire
+func Body(raw json.RawMessage) []byte {
+	var s string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return []byte(s)
+	}
+	return raw
+}
+
+// bodyValue stores a body as JSON if it is valid JSON, otherwise as a string
+func bodyValue(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	data, _ := json.Marshal(string(body))
+	return data
+}
+
+// GetCaptureDir returns the directory captures are written to
+func GetCaptureDir() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "captures"), nil
+}
+
+// newID returns a sortable, file name safe capture ID
+func newID(t time.Time) string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return t.Format("20060102-150405") + fmt.Sprintf("%03d", t.Nanosecond()/1e6) + "-" + hex.EncodeToString(suffix)
+}
+
+// save writes a capture to dir and removes the oldest captures beyond max
+func save(dir string, c *Capture, max int) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Captures hold prompts and responses, so keep them private
+	if err := os.WriteFile(filepath.Join(dir, c.ID+".json"), data, 0600); err != nil {
+		return err
+	}
+
+	if max <= 0 {
+		return nil
+	}
+	ids, err := listIDs(dir)
+	if err != nil {
+		return err
+	}
+	for len(ids) > max {
+		os.Remove(filepath.Join(dir, ids[0]+".json"))
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// listIDs returns the capture IDs in dir, oldest first
+func listIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// List returns the stored captures, oldest first
+func List() ([]*Capture, error) {
+	dir, err := GetCaptureDir()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := listIDs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Capture
+	for _, id := range ids {
+		c, err := read(dir, id)
+		if err != nil {
+			continue
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// Load returns the capture with the given ID or unique ID prefix
+func Load(id string) (*Capture, error) {
+	dir, err := GetCaptureDir()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := listIDs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, candidate := range ids {
+		if candidate == id {
+			return read(dir, candidate)
+		}
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, ErrNotFound
+	case 1:
+		return read(dir, matches[0])
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrAmbiguous, strings.Join(matches, ", "))
+	}
+}
+
+// read reads one capture file
+func read(dir, id string) (*Capture, error) {
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var c Capture
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,167 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// Redact returns a copy of headers with credential values masked
+func Redact(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		copied := make([]string, len(values))
+		for i, v := range values {
+			if config.IsSecretKey(name) {
+				v = config.MaskSecret(v)
+			}
+			copied[i] = v
+		}
+		out[name] = copied
+	}
+	return out
+}
+
+// Recorder writes captures of proxied exchanges to a directory, keeping
+// the most recent Max captures
+type Recorder struct {
+	Dir     string                     // Capture directory, see GetCaptureDir
+	Max     int                        // Captures kept, unlimited if 0
+	OnError func(id string, err error) // Called when a capture cannot be written, may be nil
+	OnSave  func(c *Capture)           // Called after a capture is written, may be nil
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a recorder for the default capture directory
+func NewRecorder(max int) (*Recorder, error) {
+	dir, err := GetCaptureDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{Dir: dir, Max: max}, nil
+}
+
+// Pending is a capture whose response has not finished yet. All methods
+// are no-ops on a nil Pending, so callers need not check for a recorder
+type Pending struct {
+	rec     *Recorder
+	capture Capture
+	start   time.Time
+	once    sync.Once
+}
+
+// Start begins capturing a request. header is what is sent upstream and is
+// stored redacted
+func (r *Recorder) Start(provider, method, path, url string, header http.Header, body []byte) *Pending {
+	if r == nil {
+		return nil
+	}
+
+	now := time.Now()
+	c := Capture{
+		ID:             newID(now),
+		Time:           now,
+		Provider:       provider,
+		Method:         method,
+		Path:           path,
+		URL:            url,
+		RequestHeaders: Redact(header),
+	}
+	if len(body) > maxBody {
+		body = body[:maxBody]
+		c.Truncated = true
+	}
+	c.RequestBody = bodyValue(body)
+	return &Pending{rec: r, capture: c, start: now}
+}
+
+// Fail records a transport error and writes the capture
+func (p *Pending) Fail(err error) {
+	if p == nil {
+		return
+	}
+	p.capture.Error = err.Error()
+	p.capture.HeaderMS = time.Since(p.start).Milliseconds()
+	p.finish()
+}
+
+// Wrap records the response headers and replaces the response body with one
+// that records the body as it is read. The capture is written when the body
+// is closed
+func (p *Pending) Wrap(resp *http.Response) {
+	if p == nil {
+		return
+	}
+	p.capture.Status = resp.StatusCode
+	p.capture.ResponseHeaders = Redact(resp.Header)
+	p.capture.HeaderMS = time.Since(p.start).Milliseconds()
+	resp.Body = &recordingBody{ReadCloser: resp.Body, pending: p}
+}
+
+// finish writes the capture once
+func (p *Pending) finish() {
+	p.once.Do(func() {
+		p.capture.DurationMS = time.Since(p.start).Milliseconds()
+
+		p.rec.mu.Lock()
+		err := save(p.rec.Dir, &p.capture, p.rec.Max)
+		p.rec.mu.Unlock()
+
+		if err != nil {
+			if p.rec.OnError != nil {
+				p.rec.OnError(p.capture.ID, err)
+			}
+			return
+		}
+		if p.rec.OnSave != nil {
+			p.rec.OnSave(&p.capture)
+		}
+	})
+}
+
+// recordingBody copies what is read from a response body into a capture
+type recordingBody struct {
+	io.ReadCloser
+	pending *Pending
+	buf     bytes.Buffer
+	eof     bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if room := maxBody - b.buf.Len(); room > 0 {
+			if n > room {
+				b.pending.capture.Truncated = true
+				b.buf.Write(p[:room])
+			} else {
+				b.buf.Write(p[:n])
+			}
+		} else {
+			b.pending.capture.Truncated = true
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	} else if err != nil {
+		b.pending.capture.Error = err.Error()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	// Error responses may be discarded unread, e.g. when a failover chain
+	// moves on, but their bodies are small and the most useful evidence
+	if b.pending.capture.Status >= 400 && !b.eof {
+		io.Copy(io.Discard, io.LimitReader(b, maxBody))
+	}
+	err := b.ReadCloser.Close()
+	b.pending.capture.ResponseBody = bodyValue(b.buf.Bytes())
+	b.pending.finish()
+	return err
+}
@@ -0,0 +1,60 @@
+package claude
+
+import "strings"
+
+// appendSuffix marks an overlay key whose array is appended instead of replacing
+const appendSuffix = "+"
+
+// mergeValue deep-merges an overlay value into a base value and returns the
+// result without modifying either argument. The merge semantics are:
+//
+//   - objects are merged key by key, recursively
+//   - arrays and scalars in the overlay replace the base value
+//   - a key ending in "+" (e.g. "allow+") appends its array to the base
+//     array under the key without the suffix, skipping duplicates
+//   - a null overlay value removes the key from the base object
+func mergeValue(base, overlay interface{}) interface{} {
+	ov, ok := overlay.(map[string]interface{})
+	if !ok {
+		return normalize(overlay)
+	}
+
+	result, ok := normalize(base).(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+	}
+
+	for key, value := range ov {
+		if name := strings.TrimSuffix(key, appendSuffix); name != key {
+			if items, ok := value.([]interface{}); ok {
+				result[name] = appendUnique(result[name], items)
+				continue
+			}
+		}
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergeValue(result[key], value)
+	}
+	return result
+}
+
+// appendUnique appends items to base (if it is an array) skipping duplicates
+func appendUnique(base interface{}, items []interface{}) []interface{} {
+	existing, _ := base.([]interface{})
+	result := append([]interface{}{}, existing...)
+	for _, item := range items {
+		found := false
+		for _, e := range result {
+			if sameValue(e, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, normalize(item))
+		}
+	}
+	return result
+}
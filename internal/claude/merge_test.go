@@ -0,0 +1,214 @@
+package claude
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// decode parses a JSON literal
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestMergeValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		overlay string
+		want    string
+	}{
+		{"scalar replaces", `{"model":"a"}`, `{"model":"b"}`, `{"model":"b"}`},
+		{"scalar replaces object", `{"x":{"y":1}}`, `{"x":2}`, `{"x":2}`},
+		{"object replaces scalar", `{"x":1}`, `{"x":{"y":2}}`, `{"x":{"y":2}}`},
+		{"array replaces", `{"allow":["a","b"]}`, `{"allow":["c"]}`, `{"allow":["c"]}`},
+		{"array appends with +", `{"allow":["a","b"]}`, `{"allow+":["b","c"]}`, `{"allow":["a","b","c"]}`},
+		{"append to missing array", `{}`, `{"allow+":["a"]}`, `{"allow":["a"]}`},
+		{"append replaces non-array", `{"allow":"a"}`, `{"allow+":["b"]}`, `{"allow":["b"]}`},
+		{"null deletes", `{"a":1,"b":2}`, `{"a":null}`, `{"b":2}`},
+		{"null on missing key", `{"b":2}`, `{"a":null}`, `{"b":2}`},
+		{"new key added", `{"a":1}`, `{"b":2}`, `{"a":1,"b":2}`},
+		{"base not an object", `[1]`, `{"a":1}`, `{"a":1}`},
+		{
+			"nested deep merge",
+			`{"permissions":{"allow":["Bash(ls)"],"deny":["Read(.env)"],"mode":"default"},"theme":"dark"}`,
+			`{"permissions":{"allow+":["Bash(git status)"],"deny":null,"mode":"plan","extra":{"a":1}}}`,
+			`{"permissions":{"allow":["Bash(ls)","Bash(git status)"],"mode":"plan","extra":{"a":1}},"theme":"dark"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := decode(t, tt.base)
+			before := decode(t, tt.base)
+			got := mergeValue(base, decode(t, tt.overlay))
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("mergeValue = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(base, before) {
+				t.Errorf("base modified to %v", base)
+			}
+		})
+	}
+}
+
+func TestRevert(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		owned    map[string]ManagedValue
+		want     string
+		modified []string
+	}{
+		{
+			"written key removed",
+			`{"a":"ccs"}`,
+			map[string]ManagedValue{"a": {Value: "ccs"}},
+			`{}`, nil,
+		},
+		{
+			"replaced user value restored",
+			`{"a":"ccs"}`,
+			map[string]ManagedValue{"a": {Value: "ccs", Previous: "user"}},
+			`{"a":"user"}`, nil,
+		},
+		{
+			"key removed by ccs restored",
+			`{}`,
+			map[string]ManagedValue{"a": {Value: nil, Previous: "user"}},
+			`{"a":"user"}`, nil,
+		},
+		{
+			"int written matches float read back",
+			`{"a":1}`,
+			map[string]ManagedValue{"a": {Value: 1}},
+			`{}`, nil,
+		},
+		{
+			"modified outside ccs left alone",
+			`{"a":"edited","b":"ccs"}`,
+			map[string]ManagedValue{"a": {Value: "ccs", Previous: "user"}, "b": {Value: "ccs"}},
+			`{"a":"edited"}`, []string{"a"},
+		},
+		{
+			"deleted outside ccs stays deleted",
+			`{}`,
+			map[string]ManagedValue{"a": {Value: "ccs", Previous: "user"}},
+			`{}`, nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := decode(t, tt.target).(map[string]interface{})
+			modified := revert(target, tt.owned)
+			if want := decode(t, tt.want); !reflect.DeepEqual(target, want) {
+				t.Errorf("target = %v, want %v", target, want)
+			}
+			if !reflect.DeepEqual(modified, tt.modified) {
+				t.Errorf("modified = %v, want %v", modified, tt.modified)
+			}
+		})
+	}
+}
+
+func TestSwitchAwayReverts(t *testing.T) {
+	user := `{
+		"env": {"ANTHROPIC_MODEL": "user-model", "EDITOR": "vim"},
+		"permissions": {"allow": ["Bash(ls)"]},
+		"theme": "dark"
+	}`
+	s := &Settings{raw: decode(t, user).(map[string]interface{})}
+
+	p := &config.Provider{
+		BaseURL: "https://a.example.com",
+		APIKey:  "sk-a",
+		Model:   "provider-model",
+		Env:     map[string]string{"HTTPS_PROXY": "http://proxy:3128"},
+		Settings: map[string]interface{}{
+			"permissions": map[string]interface{}{"allow+": []interface{}{"Bash(git status)"}},
+			"theme":       "light",
+			"statusLine":  map[string]interface{}{"type": "command"},
+		},
+	}
+	s.ApplyProvider(p)
+
+	env := s.raw["env"].(map[string]interface{})
+	if env["ANTHROPIC_MODEL"] != "provider-model" || env["HTTPS_PROXY"] != "http://proxy:3128" {
+		t.Fatalf("env not applied: %v", env)
+	}
+	wantAllow := decode(t, `["Bash(ls)","Bash(git status)"]`)
+	if got := s.raw["permissions"].(map[string]interface{})["allow"]; !reflect.DeepEqual(got, wantAllow) {
+		t.Fatalf("allow = %v, want %v", got, wantAllow)
+	}
+
+	// Changed by the user while the provider was applied
+	s.raw["theme"] = "solarized"
+	env["ANTHROPIC_BASE_URL"] = "https://elsewhere.example.com"
+
+	modified := s.ClearProviderSettings()
+	if want := []string{"ANTHROPIC_BASE_URL", "theme"}; !reflect.DeepEqual(modified, want) {
+		t.Errorf("modified = %v, want %v", modified, want)
+	}
+
+	want := decode(t, `{
+		"env": {"ANTHROPIC_MODEL": "user-model", "EDITOR": "vim", "ANTHROPIC_BASE_URL": "https://elsewhere.example.com"},
+		"permissions": {"allow": ["Bash(ls)"]},
+		"theme": "solarized"
+	}`)
+	if got := normalize(s.raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("settings after switching away = %v, want %v", got, want)
+	}
+	if len(s.state.Env) != 0 || len(s.state.Settings) != 0 {
+		t.Errorf("state not cleared: %+v", s.state)
+	}
+}
+
+func TestReapplyKeepsOriginalPrevious(t *testing.T) {
+	s := &Settings{raw: decode(t, `{"env":{"ANTHROPIC_MODEL":"user-model"}}`).(map[string]interface{})}
+
+	s.ApplyProvider(&config.Provider{BaseURL: "https://a.example.com", APIKey: "sk-a", Model: "a"})
+	s.ClearProviderSettings()
+	s.ApplyProvider(&config.Provider{BaseURL: "https://b.example.com", APIKey: "sk-b", Model: "b"})
+	s.ClearProviderSettings()
+
+	want := decode(t, `{"env":{"ANTHROPIC_MODEL":"user-model"}}`)
+	if got := normalize(s.raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("settings = %v, want %v", got, want)
+	}
+}
+
+func TestTopLevelAppendKey(t *testing.T) {
+	user := `{"env": {"EDITOR": "vim"}, "companyAnnouncements": ["Welcome"], "theme": "dark"}`
+	s := &Settings{raw: decode(t, user).(map[string]interface{})}
+
+	s.ApplyProvider(&config.Provider{
+		BaseURL: "https://a.example.com",
+		APIKey:  "sk-a",
+		Settings: map[string]interface{}{
+			"companyAnnouncements+": []interface{}{"Welcome", "Using gateway A"},
+		},
+	})
+
+	want := decode(t, `["Welcome","Using gateway A"]`)
+	if got := s.raw["companyAnnouncements"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("companyAnnouncements = %v, want %v", got, want)
+	}
+	if _, ok := s.raw["companyAnnouncements+"]; ok {
+		t.Errorf("suffixed key written to settings: %v", s.raw)
+	}
+
+	if modified := s.ClearProviderSettings(); len(modified) != 0 {
+		t.Errorf("modified = %v", modified)
+	}
+	if got := normalize(s.raw); !reflect.DeepEqual(got, decode(t, user)) {
+		t.Errorf("settings after switching away = %v, want %s", got, user)
+	}
+}
@@ -5,13 +5,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/katz/ccs/internal/config"
 )
 
-// managedEnvKeys are the env keys that ccs manages
+// managedEnvKeys are the fixed env keys that ccs manages; extra keys from
+// Provider.Env are tracked in the state file
 var managedEnvKeys = []string{
 	"ANTHROPIC_BASE_URL",
 	"ANTHROPIC_AUTH_TOKEN",
@@ -27,7 +29,8 @@ var managedEnvKeys = []string{
 // Settings wraps the raw settings.json
 // ccs only manages specific keys in "env", everything else is preserved as-is
 type Settings struct {
-	raw map[string]interface{}
+	raw   map[string]interface{}
+	state *State
 }
 
 // getEnv returns the env map, creating it if needed
@@ -102,10 +105,15 @@ func LoadSettings() (*Settings, error) {
 		return nil, err
 	}
 
+	st, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Settings{raw: make(map[string]interface{})}, nil
+			return &Settings{raw: make(map[string]interface{}), state: st}, nil
 		}
 		return nil, err
 	}
@@ -117,7 +125,7 @@ func LoadSettings() (*Settings, error) {
 		return nil, err
 	}
 
-	return &Settings{raw: raw}, nil
+	return &Settings{raw: raw, state: st}, nil
 }
 
 // backup creates a backup of the settings file
@@ -156,22 +164,26 @@ func (s *Settings) Save() error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
-}
-
-// ApplyProvider applies a provider configuration to the settings
-func (s *Settings) ApplyProvider(p *config.Provider) {
-	env := s.getEnv()
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
 
-	env["ANTHROPIC_BASE_URL"] = p.BaseURL
-	env["ANTHROPIC_AUTH_TOKEN"] = p.APIKey
+	return s.getState().save()
+}
 
-	delete(env, "API_TIMEOUT_MS")
-	if p.Timeout > 0 {
-		env["API_TIMEOUT_MS"] = strconv.Itoa(p.Timeout)
+// getState returns the state, creating it if needed
+func (s *Settings) getState() *State {
+	if s.state == nil {
+		s.state = &State{}
 	}
+	return s.state
+}
 
-	modelFields := map[string]string{
+// providerEnv returns the env values ccs writes for a provider
+func providerEnv(p *config.Provider) map[string]interface{} {
+	values := map[string]interface{}{
+		"ANTHROPIC_BASE_URL":             p.BaseURL,
+		"ANTHROPIC_AUTH_TOKEN":           p.APIKey,
 		"ANTHROPIC_MODEL":                p.Model,
 		"ANTHROPIC_SMALL_FAST_MODEL":     p.SmallModel,
 		"ANTHROPIC_DEFAULT_SONNET_MODEL": p.SonnetModel,
@@ -179,30 +191,149 @@ func (s *Settings) ApplyProvider(p *config.Provider) {
 		"ANTHROPIC_DEFAULT_HAIKU_MODEL":  p.HaikuModel,
 	}
 
-	for key, value := range modelFields {
-		delete(env, key)
-		if value != "" {
-			env[key] = value
+	if p.Timeout > 0 {
+		values["API_TIMEOUT_MS"] = strconv.Itoa(p.Timeout)
+	}
+
+	if p.DisablesNonessentialTraffic() {
+		values["CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC"] = 1
+	}
+
+	// Extra env vars are applied last so they can override the fixed keys
+	for key, value := range p.Env {
+		values[key] = value
+	}
+
+	for key, value := range values {
+		if value == "" {
+			delete(values, key)
 		}
 	}
+	return values
+}
 
-	env["CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC"] = 1
+// HasState reports whether ccs has recorded which settings it owns
+func (s *Settings) HasState() bool {
+	st := s.getState()
+	return st.exists || len(st.Env) > 0
 }
 
-// ClearProviderSettings removes provider-related settings from env
-func (s *Settings) ClearProviderSettings() {
+// AdoptProvider marks the env values matching what ccs would write for p as
+// owned by ccs. It migrates settings written before the state file existed
+func (s *Settings) AdoptProvider(p *config.Provider) {
 	env := s.getEnv()
-	for _, key := range managedEnvKeys {
-		delete(env, key)
+	st := s.getState()
+	if st.Env == nil {
+		st.Env = make(map[string]ManagedValue)
+	}
+
+	for key, value := range providerEnv(p) {
+		if current, ok := env[key]; ok && sameValue(current, value) {
+			st.Env[key] = ManagedValue{Value: current}
+		}
 	}
 }
 
-// GetCurrentEnvConfig returns a summary of the current env configuration
+// ApplyProvider applies a provider configuration to the settings.
+// Keys the provider leaves empty are not touched, and every key written is
+// recorded in the state together with the user value it replaced
+func (s *Settings) ApplyProvider(p *config.Provider) {
+	env := s.getEnv()
+	st := s.getState()
+	if st.Env == nil {
+		st.Env = make(map[string]ManagedValue)
+	}
+
+	for key, value := range providerEnv(p) {
+		mv := ManagedValue{Value: value}
+		if owned, ok := st.Env[key]; ok {
+			mv.Previous = owned.Previous
+		} else if current, ok := env[key]; ok {
+			mv.Previous = current
+		}
+		env[key] = value
+		st.Env[key] = mv
+	}
+
+	s.applyOverlay(p.Settings)
+}
+
+// applyOverlay deep-merges a provider's settings overlay into the top-level
+// settings (see mergeValue), recording each changed key in the state. The
+// "env" key is skipped since env is managed through Provider.Env
+func (s *Settings) applyOverlay(overlay map[string]interface{}) {
+	if len(overlay) == 0 {
+		return
+	}
+
+	st := s.getState()
+	if st.Settings == nil {
+		st.Settings = make(map[string]ManagedValue)
+	}
+
+	for key, value := range overlay {
+		// An appending key such as "companyAnnouncements+" changes the key
+		// without the suffix
+		name := key
+		if _, ok := value.([]interface{}); ok {
+			name = strings.TrimSuffix(key, appendSuffix)
+		}
+		if name == "env" {
+			continue
+		}
+
+		var mv ManagedValue
+		if owned, ok := st.Settings[name]; ok {
+			mv.Previous = owned.Previous
+		} else {
+			mv.Previous = s.raw[name]
+		}
+
+		merged := mergeValue(map[string]interface{}{name: mv.Previous}, map[string]interface{}{key: value})
+		mv.Value = merged.(map[string]interface{})[name]
+
+		if mv.Value == nil {
+			delete(s.raw, name)
+		} else {
+			s.raw[name] = mv.Value
+		}
+		st.Settings[name] = mv
+	}
+}
+
+// ClearProviderSettings removes the env keys and settings overlay ccs owns,
+// restoring any user value they replaced. Keys whose value was changed
+// outside ccs are left in place and returned so the caller can warn about them
+func (s *Settings) ClearProviderSettings() []string {
+	env := s.getEnv()
+	st := s.getState()
+
+	modified := revert(env, st.Env)
+	st.Env = nil
+
+	modified = append(modified, revert(s.raw, st.Settings)...)
+	st.Settings = nil
+
+	sort.Strings(modified)
+	return modified
+}
+
+// GetCurrentEnvConfig returns the env values ccs manages in settings.json,
+// including extra keys from Provider.Env. Without a state file the fixed
+// managed keys are shown
 func (s *Settings) GetCurrentEnvConfig() map[string]string {
 	result := make(map[string]string)
 	env := s.getEnv()
 
-	for _, key := range managedEnvKeys[:8] { // exclude DISABLE_NONESSENTIAL_TRAFFIC
+	keys := managedEnvKeys
+	if s.HasState() {
+		keys = make([]string, 0, len(s.getState().Env))
+		for key := range s.getState().Env {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
 		if val, ok := env[key]; ok {
 			switch v := val.(type) {
 			case string:
@@ -211,6 +342,8 @@ func (s *Settings) GetCurrentEnvConfig() map[string]string {
 				result[key] = strconv.FormatFloat(v, 'f', -1, 64)
 			case int:
 				result[key] = strconv.Itoa(v)
+			case bool:
+				result[key] = strconv.FormatBool(v)
 			}
 		}
 	}
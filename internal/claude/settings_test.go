@@ -0,0 +1,77 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+func TestGetCurrentEnvConfig(t *testing.T) {
+	s := &Settings{raw: decode(t, `{"env":{"EDITOR":"vim","ANTHROPIC_MODEL":"user-model"}}`).(map[string]interface{})}
+
+	s.ApplyProvider(&config.Provider{
+		BaseURL: "https://a.example.com",
+		APIKey:  "sk-a",
+		Timeout: 3000,
+		Env:     map[string]string{"HTTPS_PROXY": "http://proxy:3128"},
+	})
+
+	want := map[string]string{
+		"ANTHROPIC_BASE_URL":                       "https://a.example.com",
+		"ANTHROPIC_AUTH_TOKEN":                     "sk-a",
+		"API_TIMEOUT_MS":                           "3000",
+		"CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC": "1",
+		"HTTPS_PROXY":                              "http://proxy:3128",
+	}
+	if got := s.GetCurrentEnvConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("env = %v, want %v", got, want)
+	}
+}
+
+func TestGetCurrentEnvConfigWithoutState(t *testing.T) {
+	s := &Settings{raw: decode(t, `{"env":{"EDITOR":"vim","ANTHROPIC_BASE_URL":"https://old.example.com","API_TIMEOUT_MS":600000}}`).(map[string]interface{})}
+
+	want := map[string]string{
+		"ANTHROPIC_BASE_URL": "https://old.example.com",
+		"API_TIMEOUT_MS":     "600000",
+	}
+	if got := s.GetCurrentEnvConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("env = %v, want %v", got, want)
+	}
+}
+
+func TestSaveWritesPrivateState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := GetStatePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A state file left world-readable by an older version
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ApplyProvider(&config.Provider{BaseURL: "https://a.example.com", APIKey: "sk-a"})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("state.json mode = %o, want 600", mode)
+	}
+}
@@ -0,0 +1,126 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// State is the ccs-owned record of what ccs wrote into settings.json,
+// so switching providers only undoes ccs's own changes
+type State struct {
+	Env      map[string]ManagedValue `json:"env"`                // Env keys owned by ccs
+	Settings map[string]ManagedValue `json:"settings,omitempty"` // Top-level keys changed by a settings overlay
+
+	exists bool // Whether the state file was present on disk
+}
+
+// ManagedValue records a value written by ccs and the user value it replaced
+type ManagedValue struct {
+	Value    interface{} `json:"value"`              // Value written by ccs, nil if ccs removed the key
+	Previous interface{} `json:"previous,omitempty"` // Replaced user value, nil if the key was absent
+}
+
+// GetStatePath returns the path of the ccs state file
+func GetStatePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// loadState loads the state file, returning an empty state if it does not exist
+func loadState() (*State, error) {
+	path, err := GetStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	st.exists = true
+	return &st, nil
+}
+
+// save writes the state file, readable only by the user
+func (st *State) save() error {
+	path, err := GetStatePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The recorded values include ANTHROPIC_AUTH_TOKEN. WriteFile keeps the
+	// mode of an existing file, so files written by older versions are
+	// tightened too
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return err
+	}
+	st.exists = true
+	return nil
+}
+
+// sameValue reports whether two settings values are equal once normalized
+// through JSON, so an int written by ccs matches the float64 read back
+func sameValue(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// normalize round-trips a value through JSON
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// revert undoes the values ccs recorded in owned. Keys whose value no longer
+// matches what ccs wrote are left alone and returned
+func revert(target map[string]interface{}, owned map[string]ManagedValue) []string {
+	var modified []string
+	for key, mv := range owned {
+		current, ok := target[key]
+		unchanged := (!ok && mv.Value == nil) || (ok && sameValue(current, mv.Value))
+		switch {
+		case !unchanged:
+			if ok {
+				modified = append(modified, key)
+			}
+		case mv.Previous != nil:
+			target[key] = mv.Previous
+		default:
+			delete(target, key)
+		}
+	}
+	return modified
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -11,6 +12,18 @@ import (
 type Config struct {
 	CurrentProvider string     `json:"current_provider"` // Current active provider alias
 	Providers       []Provider `json:"providers"`        // List of configured providers
+
+	Proxy   ProxyConfig `json:"proxy"`             // Local switching proxy settings
+	Chains  []Chain     `json:"chains,omitempty"`  // Failover chains served by the proxy
+	Routers []Router    `json:"routers,omitempty"` // Model routers served by the proxy
+
+	Prices map[string]Price `json:"prices,omitempty"` // Prices keyed by model name or glob, for usage cost estimates
+
+	Redirects map[string]string `json:"redirects,omitempty"` // Deprecated aliases left by renames, mapped to the new alias
+
+	Order string `json:"order,omitempty"` // Display order within favorites and the rest: OrderManual or OrderRecent
+
+	Trash []TrashedProvider `json:"trash,omitempty"` // Removed providers, oldest first, until restored or emptied
 }
 
 var (
@@ -18,8 +31,28 @@ var (
 	ErrProviderExists   = errors.New("provider with this alias already exists")
 	ErrNoProviders      = errors.New("no providers configured")
 	ErrInvalidAlias     = errors.New("invalid provider alias")
+	ErrNotInTrash       = errors.New("provider not in trash")
+	ErrInvalidGlob      = errors.New("invalid glob pattern")
+	ErrChainNotFound    = errors.New("chain not found")
+	ErrRouterNotFound   = errors.New("router not found")
 )
 
+// ValidateAlias checks that an alias is usable on the command line and in
+// proxy paths: letters, digits, '.', '_' and '-', not starting with '-' or '.'
+func ValidateAlias(alias string) error {
+	if alias == "" || alias[0] == '-' || alias[0] == '.' {
+		return ErrInvalidAlias
+	}
+	for _, r := range alias {
+		valid := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '.' || r == '_' || r == '-'
+		if !valid {
+			return ErrInvalidAlias
+		}
+	}
+	return nil
+}
+
 // GetConfigDir returns the CCS configuration directory path
 func GetConfigDir() (string, error) {
 	// macOS/Linux: ~/.config/ccs
@@ -85,7 +118,34 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	// Provider keys and the proxy token are secrets
+	return writeFileAtomic(path, data, 0600)
+}
+
+// writeFileAtomic writes a file through a temporary file in the same
+// directory and a rename, so readers never see a partial file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // GetProvider returns a provider by alias
@@ -98,6 +158,26 @@ func (c *Config) GetProvider(alias string) (*Provider, error) {
 	return nil, ErrProviderNotFound
 }
 
+// GetChain returns a failover chain by alias
+func (c *Config) GetChain(alias string) (*Chain, error) {
+	for i := range c.Chains {
+		if c.Chains[i].Alias == alias {
+			return &c.Chains[i], nil
+		}
+	}
+	return nil, ErrChainNotFound
+}
+
+// GetRouter returns a model router by alias
+func (c *Config) GetRouter(alias string) (*Router, error) {
+	for i := range c.Routers {
+		if c.Routers[i].Alias == alias {
+			return &c.Routers[i], nil
+		}
+	}
+	return nil, ErrRouterNotFound
+}
+
 // GetCurrentProvider returns the current active provider
 func (c *Config) GetCurrentProvider() (*Provider, error) {
 	if c.CurrentProvider == "" {
@@ -116,22 +196,26 @@ func (c *Config) AddProvider(p Provider) error {
 
 	p.FillDefaults()
 	c.Providers = append(c.Providers, p)
+	// A new provider takes over an alias that used to redirect
+	delete(c.Redirects, p.Alias)
 	return nil
 }
 
-// UpdateProvider updates an existing provider
+// UpdateProvider updates an existing provider. A new alias must pass
+// ValidateAlias
 func (c *Config) UpdateProvider(alias string, p Provider) error {
 	for i := range c.Providers {
 		if c.Providers[i].Alias == alias {
 			if alias != p.Alias {
+				if err := ValidateAlias(p.Alias); err != nil {
+					return err
+				}
 				for j := range c.Providers {
 					if i != j && c.Providers[j].Alias == p.Alias {
 						return ErrProviderExists
 					}
 				}
-				if c.CurrentProvider == alias {
-					c.CurrentProvider = p.Alias
-				}
+				c.renameReferences(alias, p.Alias)
 			}
 			p.FillDefaults()
 			c.Providers[i] = p
@@ -141,6 +225,101 @@ func (c *Config) UpdateProvider(alias string, p Provider) error {
 	return ErrProviderNotFound
 }
 
+// RenameProvider changes a provider's alias and every reference to it. With
+// keepRedirect the old alias stays usable as a deprecated redirect
+func (c *Config) RenameProvider(oldAlias, newAlias string, keepRedirect bool) error {
+	if err := ValidateAlias(newAlias); err != nil {
+		return err
+	}
+	p, err := c.GetProvider(oldAlias)
+	if err != nil {
+		return err
+	}
+	if oldAlias == newAlias {
+		return nil
+	}
+	if _, err := c.GetProvider(newAlias); err == nil {
+		return ErrProviderExists
+	}
+
+	p.Alias = newAlias
+	c.renameReferences(oldAlias, newAlias)
+	delete(c.Redirects, newAlias)
+	if keepRedirect {
+		if c.Redirects == nil {
+			c.Redirects = make(map[string]string)
+		}
+		c.Redirects[oldAlias] = newAlias
+	}
+	return nil
+}
+
+// renameReferences points the current provider, chains, routers and
+// redirects that use a provider alias at its new alias
+func (c *Config) renameReferences(oldAlias, newAlias string) {
+	if c.CurrentProvider == oldAlias {
+		c.CurrentProvider = newAlias
+	}
+	for i := range c.Chains {
+		for j, a := range c.Chains[i].Providers {
+			if a == oldAlias {
+				c.Chains[i].Providers[j] = newAlias
+			}
+		}
+	}
+	for i := range c.Routers {
+		r := &c.Routers[i]
+		if r.Default == oldAlias {
+			r.Default = newAlias
+		}
+		for j := range r.Rules {
+			if r.Rules[j].Target == oldAlias {
+				r.Rules[j].Target = newAlias
+			}
+		}
+	}
+	for from, to := range c.Redirects {
+		if to == oldAlias {
+			c.Redirects[from] = newAlias
+		}
+	}
+}
+
+// ProviderReferences describes the chains and routers that use a provider
+// alias, such as "chain 'main'" or "router 'smart' rule 2"
+func (c *Config) ProviderReferences(alias string) []string {
+	var refs []string
+	for _, chain := range c.Chains {
+		for _, a := range chain.Providers {
+			if a == alias {
+				refs = append(refs, fmt.Sprintf("chain '%s'", chain.Alias))
+				break
+			}
+		}
+	}
+	for _, r := range c.Routers {
+		if r.Default == alias {
+			refs = append(refs, fmt.Sprintf("router '%s' default", r.Alias))
+		}
+		for i, rule := range r.Rules {
+			if rule.Target == alias {
+				refs = append(refs, fmt.Sprintf("router '%s' rule %d", r.Alias, i+1))
+			}
+		}
+	}
+	return refs
+}
+
+// ResolveRedirect returns the alias a deprecated alias redirects to. ok is
+// false if alias is not a redirect, including when a provider now uses it
+func (c *Config) ResolveRedirect(alias string) (resolved string, ok bool) {
+	if _, err := c.GetProvider(alias); err == nil {
+		return alias, false
+	}
+	resolved, ok = c.Redirects[alias]
+	return resolved, ok
+}
+
 // RemoveProvider removes a provider by alias
 func (c *Config) RemoveProvider(alias string) error {
 	for i := range c.Providers {
@@ -149,6 +328,11 @@ func (c *Config) RemoveProvider(alias string) error {
 			if c.CurrentProvider == alias {
 				c.CurrentProvider = ""
 			}
+			for from, to := range c.Redirects {
+				if to == alias {
+					delete(c.Redirects, from)
+				}
+			}
 			return nil
 		}
 	}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GlobMatch matches a glob against a whole value. Unlike path.Match, '*'
+// matches any characters including '/', so "*opus*" matches gateway model
+// IDs such as "anthropic/claude-opus-4.1". '?' matches one character,
+// [abc] and [!abc] match a character class and '\' escapes the next
+// character. A malformed pattern matches nothing
+func GlobMatch(pattern, value string) bool {
+	re, err := globRegexp(pattern)
+	return err == nil && re.MatchString(value)
+}
+
+// globRegexp converts a glob to an anchored regexp
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			} else {
+				b.WriteString(`\\`)
+			}
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) || end == i+1 {
+				return nil, ErrInvalidGlob
+			}
+			class := runes[i+1 : end]
+			b.WriteString("[")
+			if class[0] == '!' || class[0] == '^' {
+				b.WriteString("^")
+				class = class[1:]
+			}
+			for _, c := range class {
+				// Keep ranges, quote everything else
+				if c == '-' {
+					b.WriteRune(c)
+				} else {
+					b.WriteString(regexp.QuoteMeta(string(c)))
+				}
+			}
+			b.WriteString("]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.Compile(b.String())
+}
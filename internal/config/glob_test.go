@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"*opus*", "anthropic/claude-opus-4.1", true},
+		{"anthropic/*", "anthropic/claude-opus-4.1", true},
+		{"claude-*", "claude-sonnet-4", true},
+		{"claude-*", "xclaude-sonnet-4", false},
+		{"gpt-4?", "gpt-4o", true},
+		{"gpt-4?", "gpt-4", false},
+		{"gpt-4[ot]", "gpt-4t", true},
+		{"gpt-4[!ot]", "gpt-4o", false},
+		{"gpt-[0-9]*", "gpt-5", true},
+		{"a.b", "axb", false},
+		{"a+b", "a+b", true},
+		{`\*`, "*", true},
+		{`\*`, "x", false},
+		{"exact", "exact", true},
+		{"[", "[", false},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := GlobMatch(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("GlobMatch(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
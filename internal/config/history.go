@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Switch scopes for HistoryEntry.Scope, named after Claude Code's settings
+// scopes
+const (
+	ScopeUser = "user" // ~/.claude/settings.json
+)
+
+// MaxHistory bounds the switch history, dropping the oldest entries
+const MaxHistory = 100
+
+// HistoryEntry is one provider switch
+type HistoryEntry struct {
+	Alias string    `json:"alias"`
+	Time  time.Time `json:"time"`
+	Scope string    `json:"scope"`
+}
+
+// GetHistoryPath returns the path of the switch history file
+func GetHistoryPath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.json"), nil
+}
+
+// LoadHistory loads the switch history, oldest first. A missing file is an
+// empty history
+func LoadHistory() ([]HistoryEntry, error) {
+	path, err := GetHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var history []HistoryEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// SaveHistory writes the switch history, keeping the newest MaxHistory
+// entries
+func SaveHistory(history []HistoryEntry) error {
+	if len(history) > MaxHistory {
+		history = history[len(history)-MaxHistory:]
+	}
+
+	path, err := GetHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// RecordSwitch appends a switch to the history. Switching again to the
+// newest entry's provider only updates its time, so '-' keeps meaning the
+// provider before it
+func RecordSwitch(alias, scope string) error {
+	history, err := LoadHistory()
+	if err != nil {
+		// An unreadable history is started over rather than blocking switches
+		history = nil
+	}
+
+	entry := HistoryEntry{Alias: alias, Time: time.Now(), Scope: scope}
+	if n := len(history); n > 0 && history[n-1].Alias == alias && history[n-1].Scope == scope {
+		history[n-1] = entry
+	} else {
+		history = append(history, entry)
+	}
+	return SaveHistory(history)
+}
+
+// RenameHistory points the history entries of a renamed provider at its new
+// alias
+func RenameHistory(oldAlias, newAlias string) error {
+	history, err := LoadHistory()
+	if err != nil || len(history) == 0 {
+		return err
+	}
+
+	changed := false
+	for i := range history {
+		if history[i].Alias == oldAlias {
+			history[i].Alias = newAlias
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return SaveHistory(history)
+}
@@ -0,0 +1,82 @@
+package config
+
+import "sort"
+
+// Provider orders for Config.Order
+const (
+	OrderManual = ""       // The configured order, changed with 'ccs move'
+	OrderRecent = "recent" // Most recently used first
+)
+
+// MoveProvider moves a provider to index in the configured order, clamped
+// to the list
+func (c *Config) MoveProvider(alias string, index int) error {
+	from := -1
+	for i := range c.Providers {
+		if c.Providers[i].Alias == alias {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return ErrProviderNotFound
+	}
+
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(c.Providers) {
+		index = len(c.Providers) - 1
+	}
+
+	p := c.Providers[from]
+	c.Providers = append(c.Providers[:from], c.Providers[from+1:]...)
+	c.Providers = append(c.Providers[:index], append([]Provider{p}, c.Providers[index:]...)...)
+	return nil
+}
+
+// ProviderIndex returns the position of a provider in the configured order,
+// -1 if there is none with the alias
+func (c *Config) ProviderIndex(alias string) int {
+	for i := range c.Providers {
+		if c.Providers[i].Alias == alias {
+			return i
+		}
+	}
+	return -1
+}
+
+// OrderedIndexes returns the indexes of the providers in display order:
+// favorites first, then the rest, each in the configured order or, with
+// OrderRecent, most recently used first
+func (c *Config) OrderedIndexes() []int {
+	indexes := make([]int, len(c.Providers))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := &c.Providers[indexes[i]], &c.Providers[indexes[j]]
+		if a.Favorite != b.Favorite {
+			return a.Favorite
+		}
+		if c.Order == OrderRecent {
+			if a.LastUsed == nil || b.LastUsed == nil {
+				return a.LastUsed != nil
+			}
+			return a.LastUsed.After(*b.LastUsed)
+		}
+		return false
+	})
+	return indexes
+}
+
+// Ordered returns a copy of the providers in display order
+func (c *Config) Ordered() []Provider {
+	indexes := c.OrderedIndexes()
+	providers := make([]Provider, len(indexes))
+	for i, index := range indexes {
+		providers[i] = c.Providers[index]
+	}
+	return providers
+}
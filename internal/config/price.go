@@ -0,0 +1,19 @@
+package config
+
+// Price is a model's price in USD per million tokens
+type Price struct {
+	Input      float64 `json:"input"`                 // Input tokens
+	Output     float64 `json:"output"`                // Output tokens
+	CacheWrite float64 `json:"cache_write,omitempty"` // Cache creation input tokens
+	CacheRead  float64 `json:"cache_read,omitempty"`  // Cache read input tokens
+}
+
+// Budget limits a provider's consumption per calendar day and month.
+// Zero limits are not enforced
+type Budget struct {
+	DailyTokens   int64   `json:"daily_tokens,omitempty"`
+	MonthlyTokens int64   `json:"monthly_tokens,omitempty"`
+	DailyCost     float64 `json:"daily_cost,omitempty"`   // USD, estimated from Config.Prices
+	MonthlyCost   float64 `json:"monthly_cost,omitempty"` // USD, estimated from Config.Prices
+	Hard          bool    `json:"hard,omitempty"`         // Whether the proxy refuses requests once exceeded
+}
@@ -1,17 +1,56 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Provider represents a Claude Code API provider configuration
 type Provider struct {
-	Name        string `json:"name"`         // Provider display name
-	Alias       string `json:"alias"`        // Provider short alias
-	BaseURL     string `json:"base_url"`     // API Base URL
-	APIKey      string `json:"api_key"`      // API Key / Auth Token
-	Model       string `json:"model"`        // Main model (ANTHROPIC_MODEL)
-	SmallModel  string `json:"small_model"`  // Small/fast model (ANTHROPIC_SMALL_FAST_MODEL)
-	SonnetModel string `json:"sonnet_model"` // Sonnet model (ANTHROPIC_DEFAULT_SONNET_MODEL)
-	OpusModel   string `json:"opus_model"`   // Opus model (ANTHROPIC_DEFAULT_OPUS_MODEL)
-	HaikuModel  string `json:"haiku_model"`  // Haiku model (ANTHROPIC_DEFAULT_HAIKU_MODEL)
-	Timeout     int    `json:"timeout_ms"`   // API timeout in milliseconds
+	Name        string `json:"name"`                 // Provider display name
+	Alias       string `json:"alias"`                // Provider short alias
+	BaseURL     string `json:"base_url"`             // API Base URL
+	APIKey      string `json:"api_key"`              // API Key / Auth Token
+	Model       string `json:"model"`                // Main model (ANTHROPIC_MODEL)
+	SmallModel  string `json:"small_model"`          // Small/fast model (ANTHROPIC_SMALL_FAST_MODEL)
+	SonnetModel string `json:"sonnet_model"`         // Sonnet model (ANTHROPIC_DEFAULT_SONNET_MODEL)
+	OpusModel   string `json:"opus_model"`           // Opus model (ANTHROPIC_DEFAULT_OPUS_MODEL)
+	HaikuModel  string `json:"haiku_model"`          // Haiku model (ANTHROPIC_DEFAULT_HAIKU_MODEL)
+	Timeout     int    `json:"timeout_ms"`           // API timeout in milliseconds
+	APIFormat   string `json:"api_format,omitempty"` // Upstream API: "anthropic" (default) or "openai"
+
+	Tags     []string `json:"tags,omitempty"`     // Free-form labels for grouping and filtering
+	Favorite bool     `json:"favorite,omitempty"` // Listed before other providers
+
+	Env      map[string]string      `json:"env,omitempty"`      // Extra env vars written to settings.json
+	Settings map[string]interface{} `json:"settings,omitempty"` // Overlay deep-merged into settings.json
+
+	DisableNonessentialTraffic *bool `json:"disable_nonessential_traffic,omitempty"` // Nil means true
+
+	Budget *Budget `json:"budget,omitempty"` // Consumption limits tracked from proxy usage
+
+	LastUsed *time.Time `json:"last_used,omitempty"` // When 'ccs use' last switched to the provider
+}
+
+// API formats a provider can speak
+const (
+	FormatAnthropic = "anthropic"
+	FormatOpenAI    = "openai"
+)
+
+// IsOpenAI reports whether the provider speaks the OpenAI Chat Completions
+// API, which the proxy translates to and from the Messages API
+func (p *Provider) IsOpenAI() bool {
+	return p.APIFormat == FormatOpenAI
+}
+
+// DisablesNonessentialTraffic reports whether CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC
+// should be set, which is the default for providers that do not configure it
+func (p *Provider) DisablesNonessentialTraffic() bool {
+	return p.DisableNonessentialTraffic == nil || *p.DisableNonessentialTraffic
 }
 
 // FillDefaults fills empty model fields with the main model value
@@ -35,3 +74,158 @@ func (p *Provider) FillDefaults() {
 		p.Timeout = 300000 // Default 5 minutes
 	}
 }
+
+// Fields are the provider fields Set accepts by their config.json names,
+// besides env.<KEY>
+var Fields = []string{
+	"name", "alias", "base_url", "api_key", "api_format", "model", "small_model", "sonnet_model",
+	"opus_model", "haiku_model", "timeout_ms", "tags", "disable_nonessential_traffic",
+}
+
+// Set sets a field from its string form, using the config.json field name
+// or env.<KEY>. Setting model also moves the model roles that used the old
+// main model, tags takes a comma-separated list and an empty env value
+// removes the variable
+func (p *Provider) Set(field, value string) error {
+	if key, ok := strings.CutPrefix(field, "env."); ok {
+		if key == "" {
+			return errors.New("missing env var name")
+		}
+		if value == "" {
+			delete(p.Env, key)
+			return nil
+		}
+		if p.Env == nil {
+			p.Env = make(map[string]string)
+		}
+		p.Env[key] = value
+		return nil
+	}
+
+	switch field {
+	case "name":
+		p.Name = value
+	case "alias":
+		p.Alias = value
+	case "base_url":
+		p.BaseURL = value
+	case "api_key":
+		p.APIKey = value
+	case "api_format":
+		switch value {
+		case "", FormatAnthropic:
+			p.APIFormat = ""
+		case FormatOpenAI:
+			p.APIFormat = FormatOpenAI
+		default:
+			return fmt.Errorf("API format must be %s or %s", FormatAnthropic, FormatOpenAI)
+		}
+	case "model":
+		// Roles defaulted from the main model keep following it
+		for _, role := range []*string{&p.SmallModel, &p.SonnetModel, &p.OpusModel, &p.HaikuModel} {
+			if *role == p.Model {
+				*role = value
+			}
+		}
+		p.Model = value
+	case "small_model":
+		p.SmallModel = value
+	case "sonnet_model":
+		p.SonnetModel = value
+	case "opus_model":
+		p.OpusModel = value
+	case "haiku_model":
+		p.HaikuModel = value
+	case "timeout_ms":
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout < 0 {
+			return errors.New("timeout must be a number of milliseconds")
+		}
+		p.Timeout = timeout
+	case "tags":
+		tags := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+		for _, tag := range tags {
+			if err := ValidateTag(tag); err != nil {
+				return fmt.Errorf("invalid tag %q", tag)
+			}
+		}
+		p.Tags = nil
+		p.AddTags(tags...)
+	case "disable_nonessential_traffic":
+		disable, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("disable_nonessential_traffic must be true or false")
+		}
+		p.DisableNonessentialTraffic = &disable
+	default:
+		return fmt.Errorf("unknown field %q", field)
+	}
+	return nil
+}
+
+// Validate checks the fields a provider cannot do without. The alias format
+// is left to ValidateAlias, so aliases predating it can be kept
+func (p *Provider) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return errors.New("name is required")
+	case p.Alias == "":
+		return errors.New("alias is required")
+	case strings.TrimSpace(p.BaseURL) == "":
+		return errors.New("base URL is required")
+	case p.APIKey == "":
+		return errors.New("API key is required")
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the provider that shares no maps, slices or
+// pointers with it
+func (p Provider) Clone() Provider {
+	c := p
+	if p.Tags != nil {
+		c.Tags = append([]string(nil), p.Tags...)
+	}
+	if p.Env != nil {
+		c.Env = make(map[string]string, len(p.Env))
+		for k, v := range p.Env {
+			c.Env[k] = v
+		}
+	}
+	if p.Settings != nil {
+		c.Settings = copyValue(p.Settings).(map[string]interface{})
+	}
+	if p.DisableNonessentialTraffic != nil {
+		disable := *p.DisableNonessentialTraffic
+		c.DisableNonessentialTraffic = &disable
+	}
+	if p.Budget != nil {
+		budget := *p.Budget
+		c.Budget = &budget
+	}
+	if p.LastUsed != nil {
+		lastUsed := *p.LastUsed
+		c.LastUsed = &lastUsed
+	}
+	return c
+}
+
+// copyValue deep copies a decoded JSON value
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = copyValue(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = copyValue(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
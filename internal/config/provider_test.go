@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestProviderSet(t *testing.T) {
+	base := func() Provider {
+		return Provider{
+			Name: "A", Alias: "a", BaseURL: "https://a.example.com", APIKey: "sk-a",
+			Model: "m1", SmallModel: "m1", SonnetModel: "m1", OpusModel: "big", HaikuModel: "m1",
+			Env: map[string]string{"KEEP": "1", "DROP": "2"},
+		}
+	}
+	disabled := false
+
+	tests := []struct {
+		field, value string
+		want         func(p *Provider)
+		wantErr      string
+	}{
+		{field: "name", value: "B", want: func(p *Provider) { p.Name = "B" }},
+		{field: "alias", value: "b", want: func(p *Provider) { p.Alias = "b" }},
+		{field: "base_url", value: "https://b.example.com", want: func(p *Provider) { p.BaseURL = "https://b.example.com" }},
+		{field: "api_key", value: "sk-b", want: func(p *Provider) { p.APIKey = "sk-b" }},
+		{field: "api_format", value: "openai", want: func(p *Provider) { p.APIFormat = FormatOpenAI }},
+		{field: "api_format", value: "anthropic", want: func(p *Provider) {}},
+		{field: "api_format", value: "grpc", wantErr: "API format must be anthropic or openai"},
+		{field: "model", value: "m2", want: func(p *Provider) {
+			p.Model, p.SmallModel, p.SonnetModel, p.HaikuModel = "m2", "m2", "m2", "m2"
+		}},
+		{field: "opus_model", value: "huge", want: func(p *Provider) { p.OpusModel = "huge" }},
+		{field: "timeout_ms", value: "5000", want: func(p *Provider) { p.Timeout = 5000 }},
+		{field: "timeout_ms", value: "soon", wantErr: "timeout must be a number of milliseconds"},
+		{field: "timeout_ms", value: "-1", wantErr: "timeout must be a number of milliseconds"},
+		{field: "tags", value: "cn, fast,cn", want: func(p *Provider) { p.Tags = []string{"cn", "fast"} }},
+		{field: "tags", value: "a\tb", wantErr: `invalid tag "a\tb"`},
+		{field: "disable_nonessential_traffic", value: "false", want: func(p *Provider) { p.DisableNonessentialTraffic = &disabled }},
+		{field: "disable_nonessential_traffic", value: "maybe", wantErr: "disable_nonessential_traffic must be true or false"},
+		{field: "env.HTTPS_PROXY", value: "http://proxy", want: func(p *Provider) { p.Env["HTTPS_PROXY"] = "http://proxy" }},
+		{field: "env.DROP", value: "", want: func(p *Provider) { delete(p.Env, "DROP") }},
+		{field: "env.", value: "x", wantErr: "missing env var name"},
+		{field: "budget", value: "1", wantErr: `unknown field "budget"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			p := base()
+			err := p.Set(tt.field, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Set error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			want := base()
+			tt.want(&want)
+			if !reflect.DeepEqual(p, want) {
+				t.Errorf("provider = %+v, want %+v", p, want)
+			}
+		})
+	}
+}
+
+func TestProviderSetFields(t *testing.T) {
+	for _, field := range Fields {
+		var p Provider
+		if err := p.Set(field, ""); err != nil && err.Error() == `unknown field "`+field+`"` {
+			t.Errorf("Fields lists %q, which Set does not accept", field)
+		}
+	}
+}
+
+func TestProviderValidate(t *testing.T) {
+	valid := Provider{Name: "A", Alias: "a", BaseURL: "https://a.example.com", APIKey: "sk-a"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	tests := []struct {
+		change  func(p *Provider)
+		wantErr string
+	}{
+		{func(p *Provider) { p.Name = " " }, "name is required"},
+		{func(p *Provider) { p.Alias = "" }, "alias is required"},
+		{func(p *Provider) { p.BaseURL = "" }, "base URL is required"},
+		{func(p *Provider) { p.APIKey = "" }, "API key is required"},
+	}
+	for _, tt := range tests {
+		p := valid
+		tt.change(&p)
+		if err := p.Validate(); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("Validate error = %v, want %q", err, tt.wantErr)
+		}
+	}
+}
+
+func TestUpdateProviderValidatesNewAlias(t *testing.T) {
+	cfg := &Config{Providers: []Provider{{Name: "Old", Alias: "old alias"}, {Name: "B", Alias: "b"}}}
+
+	// An alias predating the rules can be kept
+	if err := cfg.UpdateProvider("old alias", Provider{Name: "Renamed", Alias: "old alias"}); err != nil {
+		t.Errorf("keeping the alias: %v", err)
+	}
+	if err := cfg.UpdateProvider("b", Provider{Name: "B", Alias: "b/c"}); !errors.Is(err, ErrInvalidAlias) {
+		t.Errorf("invalid new alias: err = %v, want ErrInvalidAlias", err)
+	}
+	if _, err := cfg.GetProvider("b"); err != nil {
+		t.Errorf("b changed by a rejected update: %v", err)
+	}
+}
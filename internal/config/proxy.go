@@ -0,0 +1,97 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"strings"
+)
+
+// DefaultProxyListen is the address the local proxy listens on by default
+const DefaultProxyListen = "127.0.0.1:8787"
+
+// ProxyConfig configures the local switching proxy
+type ProxyConfig struct {
+	Listen  string `json:"listen,omitempty"`  // Listen address, DefaultProxyListen if empty
+	Enabled bool   `json:"enabled,omitempty"` // Whether ccs use points Claude Code at the proxy
+	Token   string `json:"token,omitempty"`   // Random token clients send to the proxy instead of a provider key
+}
+
+// EnsureProxyToken returns the proxy token, generating a random one and
+// saving the config the first time
+func (c *Config) EnsureProxyToken() (string, error) {
+	if c.Proxy.Token != "" {
+		return c.Proxy.Token, nil
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	c.Proxy.Token = "ccs-" + hex.EncodeToString(buf)
+	return c.Proxy.Token, c.Save()
+}
+
+// IsProxyURL reports whether a base URL points at the proxy
+func (pc ProxyConfig) IsProxyURL(baseURL string) bool {
+	base := strings.TrimSuffix(baseURL, "/")
+	return base == pc.URL() || strings.HasPrefix(base, pc.URL()+"/")
+}
+
+// Addr returns the address the proxy listens on
+func (pc ProxyConfig) Addr() string {
+	if pc.Listen == "" {
+		return DefaultProxyListen
+	}
+	return pc.Listen
+}
+
+// URL returns the base URL Claude Code uses to reach the proxy
+func (pc ProxyConfig) URL() string {
+	host, port, err := net.SplitHostPort(pc.Addr())
+	if err != nil {
+		return "http://" + pc.Addr()
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// IsLoopback reports whether a listen address or Host header names this
+// machine. An empty host listens on every interface
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Chain is a virtual provider served by the proxy at /chain/<alias>, which
+// tries the listed providers in order until one succeeds
+type Chain struct {
+	Alias     string   `json:"alias"`     // Chain name used in the proxy path
+	Providers []string `json:"providers"` // Provider aliases in fallback order
+}
+
+// Router is a virtual provider served by the proxy at /route/<alias>, which
+// picks a target per request using the first matching rule
+type Router struct {
+	Alias   string      `json:"alias"`   // Router name used in the proxy path
+	Rules   []RouteRule `json:"rules"`   // Rules checked in order
+	Default string      `json:"default"` // Target when no rule matches
+}
+
+// RouteRule selects a target when all of its set conditions match
+type RouteRule struct {
+	Model        string `json:"model,omitempty"`         // Glob (see GlobMatch) matched against the requested model, case-insensitive
+	Header       string `json:"header,omitempty"`        // "Name" (present) or "Name=glob" matched against the header value
+	MinBytes     int    `json:"min_bytes,omitempty"`     // Minimum request body size
+	MaxBytes     int    `json:"max_bytes,omitempty"`     // Maximum request body size
+	Target       string `json:"target"`                  // Provider or chain alias
+	RewriteModel bool   `json:"rewrite_model,omitempty"` // Map the model onto the target's opus/sonnet/haiku models
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:8787", true},
+		{"127.0.0.2:8787", true},
+		{"localhost:8787", true},
+		{"[::1]:8787", true},
+		{":8787", false},
+		{"0.0.0.0:8787", false},
+		{"[::]:8787", false},
+		{"[::1]", true},
+		{"localhost", true},
+		{"192.168.1.10:8787", false},
+		{"example.com:8787", false},
+	}
+	for _, tt := range tests {
+		if got := IsLoopback(tt.addr); got != tt.want {
+			t.Errorf("IsLoopback(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestEnsureProxyToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &Config{}
+	token, err := cfg.EnsureProxyToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != len("ccs-")+32 || token[:4] != "ccs-" {
+		t.Errorf("token = %q", token)
+	}
+	if again, _ := cfg.EnsureProxyToken(); again != token {
+		t.Errorf("token changed to %q", again)
+	}
+	if other, _ := (&Config{}).EnsureProxyToken(); other == token {
+		t.Error("tokens are not random")
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _ := GetConfigPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config.json permissions = %o, want 600", perm)
+	}
+	// The last config saved holds the other token
+	if loaded.Proxy.Token == "" {
+		t.Error("token not saved")
+	}
+}
+
+func TestIsProxyURL(t *testing.T) {
+	pc := ProxyConfig{}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://127.0.0.1:8787", true},
+		{"http://127.0.0.1:8787/", true},
+		{"http://127.0.0.1:8787/chain/main", true},
+		{"http://127.0.0.1:87870", false},
+		{"https://api.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := pc.IsProxyURL(tt.url); got != tt.want {
+			t.Errorf("IsProxyURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestProviderReferences(t *testing.T) {
+	cfg := &Config{
+		Chains: []Chain{
+			{Alias: "main", Providers: []string{"a", "b"}},
+			{Alias: "other", Providers: []string{"b"}},
+		},
+		Routers: []Router{{
+			Alias:   "smart",
+			Default: "a",
+			Rules:   []RouteRule{{Model: "*haiku*", Target: "b"}, {Target: "a"}},
+		}},
+	}
+
+	tests := []struct {
+		alias string
+		want  []string
+	}{
+		{"a", []string{"chain 'main'", "router 'smart' default", "router 'smart' rule 2"}},
+		{"b", []string{"chain 'main'", "chain 'other'", "router 'smart' rule 1"}},
+		{"c", nil},
+	}
+	for _, tt := range tests {
+		if got := cfg.ProviderReferences(tt.alias); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ProviderReferences(%q) = %q, want %q", tt.alias, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import "strings"
+
+// secretWords mark env var and header names whose values are credentials
+var secretWords = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "AUTH", "COOKIE"}
+
+// IsSecretKey reports whether an env var or header name suggests a secret
+// value, ignoring case
+func IsSecretKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, word := range secretWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskSecret hides a secret, keeping a Bearer or Basic auth scheme and the
+// last four characters of long values so keys can be told apart
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	scheme := ""
+	if word, rest, ok := strings.Cut(s, " "); ok && (strings.EqualFold(word, "Bearer") || strings.EqualFold(word, "Basic")) {
+		scheme, s = word+" ", rest
+	}
+	if len(s) < 12 {
+		return scheme + "****"
+	}
+	return scheme + "****" + s[len(s)-4:]
+}
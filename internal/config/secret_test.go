@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestIsSecretKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"ANTHROPIC_AUTH_TOKEN", true},
+		{"OPENAI_API_KEY", true},
+		{"AWS_SECRET_ACCESS_KEY", true},
+		{"DB_PASSWORD", true},
+		{"x-api-key", true},
+		{"Authorization", true},
+		{"Proxy-Authorization", true},
+		{"Cookie", true},
+		{"anthropic-version", false},
+		{"Content-Type", false},
+		{"HTTPS_PROXY", false},
+		{"API_TIMEOUT_MS", false},
+	}
+	for _, tt := range tests {
+		if got := IsSecretKey(tt.key); got != tt.want {
+			t.Errorf("IsSecretKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "****"},
+		{"sk-ant-api03-abcdef", "****cdef"},
+		{"Bearer sk-ant-api03-abcdef", "Bearer ****cdef"},
+		{"bearer short", "bearer ****"},
+		{"Basic dXNlcjpwYXNzd29yZA==", "Basic ****ZA=="},
+		{"user pass phrase here", "****here"},
+	}
+	for _, tt := range tests {
+		if got := MaskSecret(tt.value); got != tt.want {
+			t.Errorf("MaskSecret(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
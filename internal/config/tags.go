@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidTag is returned for empty tags or tags containing whitespace or commas
+var ErrInvalidTag = errors.New("invalid tag")
+
+// ValidateTag checks that a tag is a single non-empty word
+func ValidateTag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, " \t\r\n,") {
+		return ErrInvalidTag
+	}
+	return nil
+}
+
+// HasTag reports whether the provider has a tag
+func (p *Provider) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags adds tags the provider does not have yet, keeping them sorted
+func (p *Provider) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !p.HasTag(tag) {
+			p.Tags = append(p.Tags, tag)
+		}
+	}
+	sort.Strings(p.Tags)
+}
+
+// RemoveTags removes tags from the provider
+func (p *Provider) RemoveTags(tags ...string) {
+	// A new slice, since copies of the provider may share the old one
+	var kept []string
+	for _, t := range p.Tags {
+		remove := false
+		for _, tag := range tags {
+			if t == tag {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			kept = append(kept, t)
+		}
+	}
+	p.Tags = kept
+}
+
+// Tags returns every tag used by a provider, sorted
+func (c *Config) Tags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, p := range c.Providers {
+		for _, t := range p.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,68 @@
+package config
+
+import "time"
+
+// TrashedProvider is a removed provider kept for restoring
+type TrashedProvider struct {
+	Provider  Provider  `json:"provider"`
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+// TrashProvider removes a provider, keeping it in the trash
+func (c *Config) TrashProvider(alias string) error {
+	p, err := c.GetProvider(alias)
+	if err != nil {
+		return err
+	}
+	trashed := TrashedProvider{Provider: p.Clone(), RemovedAt: time.Now()}
+
+	if err := c.RemoveProvider(alias); err != nil {
+		return err
+	}
+	c.Trash = append(c.Trash, trashed)
+	return nil
+}
+
+// RestoreProvider moves the most recently trashed provider with alias back
+// to the end of the providers
+func (c *Config) RestoreProvider(alias string) (*Provider, error) {
+	for i := len(c.Trash) - 1; i >= 0; i-- {
+		if c.Trash[i].Provider.Alias != alias {
+			continue
+		}
+		if err := c.AddProvider(c.Trash[i].Provider); err != nil {
+			return nil, err
+		}
+		c.Trash = append(c.Trash[:i], c.Trash[i+1:]...)
+		return &c.Providers[len(c.Providers)-1], nil
+	}
+	return nil, ErrNotInTrash
+}
+
+// EmptyTrash permanently deletes the trashed providers with the given
+// aliases, or all of them if none are given, returning how many were
+// deleted
+func (c *Config) EmptyTrash(aliases ...string) int {
+	if len(aliases) == 0 {
+		n := len(c.Trash)
+		c.Trash = nil
+		return n
+	}
+
+	deleted := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		deleted[alias] = true
+	}
+	kept := c.Trash[:0]
+	for _, t := range c.Trash {
+		if !deleted[t.Provider.Alias] {
+			kept = append(kept, t)
+		}
+	}
+	n := len(c.Trash) - len(kept)
+	c.Trash = kept
+	if len(c.Trash) == 0 {
+		c.Trash = nil
+	}
+	return n
+}
@@ -0,0 +1,61 @@
+package display
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"openrouter", 10},
+		{"深度求索", 8},
+		{"한국어", 6},
+		{"ｆｕｌｌ", 8},
+		{"カタカナ", 8},
+		{"★ fav", 5},
+		{"🚀", 2},
+		{"é", 1},
+		{"a\tb", 2},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		tail  string
+		want  string
+	}{
+		{"openrouter", 20, "...", "openrouter"},
+		{"openrouter", 7, "...", "open..."},
+		{"深度求索平台", 7, "...", "深度..."},
+		{"深度求索平台", 8, "...", "深度..."},
+		{"深度求索平台", 5, "~", "深度~"},
+		{"深度求索平台", 3, "...", "深"},
+		{"abc", 0, "~", ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.width, tt.tail)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d, %q) = %q, want %q", tt.s, tt.width, tt.tail, got, tt.want)
+		}
+		if Width(got) > tt.width && tt.width < Width(tt.s) {
+			t.Errorf("Truncate(%q, %d) is %d columns wide", tt.s, tt.width, Width(got))
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	if got := Pad("深度", 6); got != "深度  " {
+		t.Errorf("Pad = %q", got)
+	}
+	if got := Pad("openrouter", 4); got != "openrouter" {
+		t.Errorf("Pad = %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package health
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/probe"
+)
+
+// Retention is how long samples are kept
+const Retention = 30 * 24 * time.Hour
+
+// Mode is the kind of probe a sample comes from. Their latencies are not
+// comparable, so stats are computed per mode
+type Mode string
+
+const (
+	ModePing Mode = "ping" // Connectivity only (GET /v1/models)
+	ModeFull Mode = "full" // Also a request per configured model
+)
+
+// ProbeMode returns the mode of a probe with or without --full
+func ProbeMode(full bool) Mode {
+	if full {
+		return ModeFull
+	}
+	return ModePing
+}
+
+// Sample is the outcome of one health probe
+type Sample struct {
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider"` // Provider alias
+	Mode      Mode      `json:"mode,omitempty"`
+	OK        bool      `json:"ok"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ProbeMode returns the sample's mode. Samples recorded before modes were
+// tracked count as pings
+func (s *Sample) ProbeMode() Mode {
+	if s.Mode == "" {
+		return ModePing
+	}
+	return s.Mode
+}
+
+// FromResult converts a probe result taken in mode into a sample. In full
+// mode the latency is that of the first model request, which reflects
+// inference rather than only connectivity
+func FromResult(r probe.Result, mode Mode) Sample {
+	s := Sample{
+		Time:      r.CheckedAt,
+		Provider:  r.Alias,
+		Mode:      mode,
+		OK:        r.OK,
+		LatencyMS: r.LatencyMS,
+		Error:     r.Error,
+	}
+	if mode == ModeFull && len(r.Models) > 0 {
+		s.LatencyMS = r.Models[0].LatencyMS
+	}
+	if s.Error == "" && !s.OK {
+		for _, m := range r.Models {
+			if !m.Accepted {
+				s.Error = m.Model + ": " + m.Error
+				break
+			}
+		}
+	}
+	return s
+}
+
+// Store is an append-only JSONL file of health samples
+type Store struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// GetHealthPath returns the path of the health history
+func GetHealthPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "health.jsonl"), nil
+}
+
+// Open returns the default health store
+func Open() (*Store, error) {
+	path, err := GetHealthPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Path: path}, nil
+}
+
+// Append adds samples to the store
+func (s *Store) Append(samples []Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var buf []byte
+	for _, sample := range samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+	_, err = f.Write(buf)
+	return err
+}
+
+// Load returns the samples at or after since. Malformed lines are skipped
+func (s *Store) Load(since time.Time) ([]Sample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(since)
+}
+
+func (s *Store) load(since time.Time) ([]Sample, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue
+		}
+		if sample.Time.Before(since) {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+// Prune rewrites the store without the samples before before
+func (s *Store) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples, err := s.load(before)
+	if err != nil {
+		return err
+	}
+	return s.rewrite(samples)
+}
+
+// Rename points the samples of a renamed provider at its new alias, so its
+// history keeps counting in 'ccs health' and 'ccs use --best'
+func (s *Store) Rename(oldAlias, newAlias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples, err := s.load(time.Time{})
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range samples {
+		if samples[i].Provider == oldAlias {
+			samples[i].Provider = newAlias
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.rewrite(samples)
+}
+
+// rewrite replaces the store with samples
+func (s *Store) rewrite(samples []Sample) error {
+	var buf []byte
+	for _, sample := range samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
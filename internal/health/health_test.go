@@ -0,0 +1,81 @@
+package health
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katz/ccs/internal/probe"
+)
+
+func TestStoreRename(t *testing.T) {
+	store := &Store{Path: filepath.Join(t.TempDir(), "health.jsonl")}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Append([]Sample{
+		{Time: now, Provider: "old", OK: true, LatencyMS: 100},
+		{Time: now, Provider: "other", OK: true, LatencyMS: 200},
+		{Time: now, Provider: "old", OK: false, Error: "timeout"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Rename("old", "new"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	samples, err := store.Load(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"new", "other", "new"}
+	if len(samples) != len(want) {
+		t.Fatalf("samples = %+v", samples)
+	}
+	for i, s := range samples {
+		if s.Provider != want[i] {
+			t.Errorf("samples[%d].Provider = %q, want %q", i, s.Provider, want[i])
+		}
+	}
+	if samples[2].Error != "timeout" {
+		t.Errorf("sample changed: %+v", samples[2])
+	}
+}
+
+func TestSummarizeByMode(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{Time: now, Provider: "a", OK: true, LatencyMS: 100}, // Recorded before modes
+		{Time: now, Provider: "a", Mode: ModePing, OK: true, LatencyMS: 120},
+		{Time: now, Provider: "a", Mode: ModeFull, OK: true, LatencyMS: 2000},
+		{Time: now, Provider: "a", Mode: ModeFull, OK: false, Error: "overloaded"},
+	}
+
+	tests := []struct {
+		mode     Mode
+		samples  int
+		failures int
+		p50      int64
+	}{
+		{ModePing, 2, 0, 100},
+		{ModeFull, 2, 1, 2000},
+	}
+	for _, tt := range tests {
+		st := Summarize(samples, []string{"a", "b"}, tt.mode)
+		if st[0].Mode != tt.mode || st[0].Samples != tt.samples || st[0].Failures != tt.failures || st[0].P50MS != tt.p50 {
+			t.Errorf("%s: stats = %+v", tt.mode, st[0])
+		}
+		if st[1].Provider != "b" || st[1].Samples != 0 {
+			t.Errorf("%s: stats for b = %+v", tt.mode, st[1])
+		}
+	}
+}
+
+func TestFromResultMode(t *testing.T) {
+	r := probe.Result{Alias: "a", OK: true, LatencyMS: 50, Models: []probe.ModelResult{{Model: "m", Accepted: true, LatencyMS: 900}}}
+	if s := FromResult(r, ModePing); s.LatencyMS != 50 || s.Mode != ModePing {
+		t.Errorf("ping sample = %+v", s)
+	}
+	if s := FromResult(r, ModeFull); s.LatencyMS != 900 || s.Mode != ModeFull {
+		t.Errorf("full sample = %+v", s)
+	}
+}
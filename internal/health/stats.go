@@ -0,0 +1,112 @@
+package health
+
+import "sort"
+
+// Stats summarizes the samples of one provider
+type Stats struct {
+	Provider    string  `json:"provider"`
+	Mode        Mode    `json:"mode"` // Kind of probes the stats cover
+	Samples     int     `json:"samples"`
+	Failures    int     `json:"failures"`
+	ErrorRate   float64 `json:"error_rate"` // Failures / samples, 0 to 1
+	P50MS       int64   `json:"p50_ms"`     // Median latency of successful samples
+	P95MS       int64   `json:"p95_ms"`
+	Last        *Sample `json:"last,omitempty"`
+	LastFailure *Sample `json:"last_failure,omitempty"`
+}
+
+// Summarize returns stats over the samples taken in mode for each alias in
+// order, including aliases without samples
+func Summarize(samples []Sample, aliases []string, mode Mode) []Stats {
+	byAlias := make(map[string][]Sample)
+	for _, s := range samples {
+		if s.ProbeMode() != mode {
+			continue
+		}
+		byAlias[s.Provider] = append(byAlias[s.Provider], s)
+	}
+
+	stats := make([]Stats, len(aliases))
+	for i, alias := range aliases {
+		stats[i] = summarize(alias, mode, byAlias[alias])
+	}
+	return stats
+}
+
+// summarize computes the stats of one provider's samples
+func summarize(alias string, mode Mode, samples []Sample) Stats {
+	st := Stats{Provider: alias, Mode: mode, Samples: len(samples)}
+
+	var latencies []int64
+	for i := range samples {
+		s := &samples[i]
+		if st.Last == nil || s.Time.After(st.Last.Time) {
+			st.Last = s
+		}
+		if !s.OK {
+			st.Failures++
+			if st.LastFailure == nil || s.Time.After(st.LastFailure.Time) {
+				st.LastFailure = s
+			}
+			continue
+		}
+		latencies = append(latencies, s.LatencyMS)
+	}
+
+	if st.Samples > 0 {
+		st.ErrorRate = float64(st.Failures) / float64(st.Samples)
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	st.P50MS = percentile(latencies, 50)
+	st.P95MS = percentile(latencies, 95)
+	return st
+}
+
+// percentile returns the nearest-rank percentile of sorted values, 0 if empty
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Candidate is a provider considered for automatic selection
+type Candidate struct {
+	Sample Sample  // Result of the probe just taken
+	Stats  Stats   // Recorded history, excluding Sample
+	Score  float64 // Lower is better, see Rank
+}
+
+// Latency returns the candidate's expected latency: the probe latency,
+// averaged with the historical median when there is history
+func (c *Candidate) Latency() float64 {
+	latency := float64(c.Sample.LatencyMS)
+	if c.Stats.P50MS > 0 {
+		latency = (latency + float64(c.Stats.P50MS)) / 2
+	}
+	return latency
+}
+
+// Rank scores candidates and orders them best first. A candidate that
+// failed its probe is ranked after all others. The score is the expected
+// latency, inflated by the historical error rate so that a fast but flaky
+// provider loses to a slightly slower reliable one
+func Rank(candidates []Candidate) {
+	for i := range candidates {
+		c := &candidates[i]
+		c.Score = c.Latency() * (1 + 4*c.Stats.ErrorRate)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Sample.OK != b.Sample.OK {
+			return a.Sample.OK
+		}
+		return a.Score < b.Score
+	})
+}
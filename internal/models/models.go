@@ -0,0 +1,183 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/probe"
+)
+
+// CacheTTL is how long a discovered model list is reused
+const CacheTTL = 24 * time.Hour
+
+// maxPages bounds pagination of Anthropic-style listings
+const maxPages = 20
+
+// Model is a model advertised by a provider
+type Model struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// cacheEntry is the on-disk cache of one provider's model list
+type cacheEntry struct {
+	BaseURL   string    `json:"base_url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Models    []Model   `json:"models"`
+}
+
+// listResponse covers both Anthropic and OpenAI style listings. Both use a
+// "data" array of objects with an "id"; Anthropic adds display names and
+// has_more/last_id pagination
+type listResponse struct {
+	Data []struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+// Discover returns the provider's models from the cache, fetching them when
+// the cache is missing, stale, for another base URL, or refresh is set
+func Discover(ctx context.Context, p *config.Provider, refresh bool) ([]Model, error) {
+	if !refresh {
+		if entry, err := readCache(p.Alias); err == nil &&
+			entry.BaseURL == p.BaseURL && time.Since(entry.FetchedAt) < CacheTTL {
+			return entry.Models, nil
+		}
+	}
+
+	list, err := Fetch(ctx, http.DefaultClient, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed cache write only costs a refetch next time
+	writeCache(p.Alias, cacheEntry{BaseURL: p.BaseURL, FetchedAt: time.Now(), Models: list})
+	return list, nil
+}
+
+// Fetch queries the provider's /v1/models endpoint, following Anthropic-style
+// pagination, and returns the models sorted by ID
+func Fetch(ctx context.Context, client *http.Client, p *config.Provider) ([]Model, error) {
+	var list []Model
+	afterID := ""
+	for page := 0; page < maxPages; page++ {
+		resp, err := fetchPage(ctx, client, p, afterID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Data {
+			list = append(list, Model{ID: d.ID, DisplayName: d.DisplayName})
+		}
+		if !resp.HasMore || resp.LastID == "" {
+			break
+		}
+		afterID = resp.LastID
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// fetchPage fetches one page of the model listing
+func fetchPage(ctx context.Context, client *http.Client, p *config.Provider, afterID string) (*listResponse, error) {
+	endpoint := probe.EndpointURL(p, "/v1/models") + "?limit=1000"
+	if afterID != "" {
+		endpoint += "&after_id=" + url.QueryEscape(afterID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	probe.SetAuthHeaders(req.Header, p.APIKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("models endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	var parsed listResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("unexpected models response: %v", err)
+	}
+	return &parsed, nil
+}
+
+// IDs returns the model IDs
+func IDs(list []Model) []string {
+	ids := make([]string, len(list))
+	for i, m := range list {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// getCachePath returns the cache file path for a provider alias
+func getCachePath(alias string) (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache", "models", url.PathEscape(alias)+".json"), nil
+}
+
+// readCache reads the cached model list for a provider alias
+func readCache(alias string) (*cacheEntry, error) {
+	path, err := getCachePath(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// writeCache writes the cached model list for a provider alias
+func writeCache(alias string, entry cacheEntry) error {
+	path, err := getCachePath(alias)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
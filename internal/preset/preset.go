@@ -0,0 +1,115 @@
+package preset
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+//go:embed presets.json
+var builtinData []byte
+
+var ErrPresetNotFound = errors.New("preset not found")
+
+// Preset is a provider template for a common gateway
+type Preset struct {
+	Name        string `json:"name"`         // Preset identifier used with --preset
+	DisplayName string `json:"display_name"` // Default provider display name
+	Description string `json:"description"`  // Short description shown in lists
+	BaseURL     string `json:"base_url"`     // API Base URL
+	Model       string `json:"model"`        // Main model
+	SmallModel  string `json:"small_model"`  // Small/fast model
+	SonnetModel string `json:"sonnet_model"` // Sonnet model
+	OpusModel   string `json:"opus_model"`   // Opus model
+	HaikuModel  string `json:"haiku_model"`  // Haiku model
+	Timeout     int    `json:"timeout_ms"`   // API timeout in milliseconds
+
+	Builtin bool `json:"-"` // Whether the preset comes from the embedded catalog
+}
+
+// Provider returns a provider pre-filled from the preset, without an API key
+func (p *Preset) Provider() config.Provider {
+	return config.Provider{
+		Name:        p.DisplayName,
+		Alias:       p.Name,
+		BaseURL:     p.BaseURL,
+		Model:       p.Model,
+		SmallModel:  p.SmallModel,
+		SonnetModel: p.SonnetModel,
+		OpusModel:   p.OpusModel,
+		HaikuModel:  p.HaikuModel,
+		Timeout:     p.Timeout,
+	}
+}
+
+// GetUserPresetsPath returns the path of the user presets file, whose
+// entries override built-in presets with the same name
+func GetUserPresetsPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "presets.json"), nil
+}
+
+// Load returns the built-in presets merged with the user presets file,
+// sorted by name
+func Load() ([]Preset, error) {
+	var builtin []Preset
+	if err := json.Unmarshal(builtinData, &builtin); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]Preset)
+	for _, p := range builtin {
+		p.Builtin = true
+		byName[p.Name] = p
+	}
+
+	path, err := GetUserPresetsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var user []Preset
+		if err := json.Unmarshal(data, &user); err != nil {
+			return nil, err
+		}
+		for _, p := range user {
+			byName[p.Name] = p
+		}
+	}
+
+	presets := make([]Preset, 0, len(byName))
+	for _, p := range byName {
+		presets = append(presets, p)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+// Get returns the preset with the given name
+func Get(name string) (*Preset, error) {
+	presets, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range presets {
+		if presets[i].Name == name {
+			return &presets[i], nil
+		}
+	}
+	return nil, ErrPresetNotFound
+}
@@ -0,0 +1,294 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// AnthropicVersion is the API version header sent with every request
+const AnthropicVersion = "2023-06-01"
+
+// DefaultTimeout is used for providers without a configured timeout
+const DefaultTimeout = 30 * time.Second
+
+// Result is the outcome of probing one provider
+type Result struct {
+	Alias      string        `json:"alias"`
+	Name       string        `json:"name"`
+	BaseURL    string        `json:"base_url"`
+	OK         bool          `json:"ok"`               // Reachable, authorized and all models accepted
+	Status     int           `json:"status"`           // HTTP status of the connectivity request
+	LatencyMS  int64         `json:"latency_ms"`       // Latency of the connectivity request
+	AuthFailed bool          `json:"auth_failed"`      // Whether the provider rejected the token
+	Error      string        `json:"error,omitempty"`  // Connectivity error, if any
+	Models     []ModelResult `json:"models,omitempty"` // One entry per distinct configured model
+	CheckedAt  time.Time     `json:"checked_at"`       // When the probe started
+}
+
+// ModelResult is the outcome of a minimal Messages API request for one model
+type ModelResult struct {
+	Model     string   `json:"model"`
+	Roles     []string `json:"roles"` // Provider fields using this model: main, sonnet, opus, haiku, small
+	Accepted  bool     `json:"accepted"`
+	Status    int      `json:"status"`
+	LatencyMS int64    `json:"latency_ms"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Prober sends probe requests to providers
+type Prober struct {
+	Client   *http.Client  // HTTP client, http.DefaultClient if nil
+	Timeout  time.Duration // Per-request timeout overriding the provider's, if set
+	PingOnly bool          // Only check connectivity and auth (see Ping)
+}
+
+// ProbeAll probes providers concurrently, returning results in input order
+func (pr *Prober) ProbeAll(ctx context.Context, providers []config.Provider) []Result {
+	results := make([]Result, len(providers))
+	var wg sync.WaitGroup
+	for i := range providers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = pr.Probe(ctx, &providers[i])
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// Probe checks connectivity and auth with GET /v1/models, then sends a
+// minimal Messages API request for each distinct configured model
+func (pr *Prober) Probe(ctx context.Context, p *config.Provider) Result {
+	if pr.PingOnly {
+		return pr.Ping(ctx, p)
+	}
+
+	res := Result{
+		Alias:     p.Alias,
+		Name:      p.Name,
+		BaseURL:   p.BaseURL,
+		CheckedAt: time.Now(),
+	}
+
+	status, latency, body, err := pr.do(ctx, p, http.MethodGet, "/v1/models", nil)
+	res.Status = status
+	res.LatencyMS = latency.Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if isAuthFailure(status) {
+		res.AuthFailed = true
+		res.Error = errorMessage(status, body)
+		return res
+	}
+
+	models := configuredModels(p)
+	if len(models) == 0 && status >= 500 {
+		res.Error = errorMessage(status, body)
+		return res
+	}
+
+	res.OK = true
+	for _, m := range models {
+		mr := pr.probeModel(ctx, p, m.Model)
+		mr.Roles = m.Roles
+		if isAuthFailure(mr.Status) {
+			res.AuthFailed = true
+		}
+		if !mr.Accepted {
+			res.OK = false
+		}
+		res.Models = append(res.Models, mr)
+	}
+	if res.AuthFailed {
+		res.OK = false
+	}
+	return res
+}
+
+// Ping checks only connectivity and auth with GET /v1/models, without
+// sending Messages API requests that may cost tokens
+func (pr *Prober) Ping(ctx context.Context, p *config.Provider) Result {
+	res := Result{
+		Alias:     p.Alias,
+		Name:      p.Name,
+		BaseURL:   p.BaseURL,
+		CheckedAt: time.Now(),
+	}
+
+	status, latency, body, err := pr.do(ctx, p, http.MethodGet, "/v1/models", nil)
+	res.Status = status
+	res.LatencyMS = latency.Milliseconds()
+	switch {
+	case err != nil:
+		res.Error = err.Error()
+	case isAuthFailure(status):
+		res.AuthFailed = true
+		res.Error = errorMessage(status, body)
+	case status >= 500:
+		res.Error = errorMessage(status, body)
+	default:
+		res.OK = true
+	}
+	return res
+}
+
+// probeModel sends a one-token Messages API request for model
+func (pr *Prober) probeModel(ctx context.Context, p *config.Provider, model string) ModelResult {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":      model,
+		"max_tokens": 1,
+		"messages": []map[string]string{
+			{"role": "user", "content": "ping"},
+		},
+	})
+
+	mr := ModelResult{Model: model}
+	status, latency, respBody, err := pr.do(ctx, p, http.MethodPost, "/v1/messages", body)
+	mr.Status = status
+	mr.LatencyMS = latency.Milliseconds()
+	switch {
+	case err != nil:
+		mr.Error = err.Error()
+	case status >= 200 && status < 300:
+		mr.Accepted = true
+	default:
+		mr.Error = errorMessage(status, respBody)
+	}
+	return mr
+}
+
+// do sends an authenticated request to the provider and reads the response
+func (pr *Prober) do(ctx context.Context, p *config.Provider, method, path string, body []byte) (int, time.Duration, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, pr.timeout(p))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, EndpointURL(p, path), bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	SetAuthHeaders(req.Header, p.APIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := pr.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, time.Since(start), nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, time.Since(start), respBody, err
+}
+
+// timeout returns the per-request timeout for a provider
+func (pr *Prober) timeout(p *config.Provider) time.Duration {
+	if pr.Timeout > 0 {
+		return pr.Timeout
+	}
+	if p.Timeout > 0 {
+		return time.Duration(p.Timeout) * time.Millisecond
+	}
+	return DefaultTimeout
+}
+
+// JoinURL joins a provider base URL and an API path
+func JoinURL(baseURL, path string) string {
+	return strings.TrimRight(baseURL, "/") + path
+}
+
+// EndpointURL returns the URL of an Anthropic API path on a provider. For
+// OpenAI-format providers, whose base URL includes the version prefix, the
+// models and Messages paths map to /models and /chat/completions
+func EndpointURL(p *config.Provider, path string) string {
+	if p.IsOpenAI() {
+		switch path {
+		case "/v1/models":
+			path = "/models"
+		case "/v1/messages":
+			path = "/chat/completions"
+		}
+	}
+	return JoinURL(p.BaseURL, path)
+}
+
+// SetAuthHeaders sets the headers Claude Code sends for a provider token.
+// Both forms are set since gateways differ in which one they accept
+func SetAuthHeaders(h http.Header, apiKey string) {
+	h.Set("x-api-key", apiKey)
+	h.Set("Authorization", "Bearer "+apiKey)
+	h.Set("anthropic-version", AnthropicVersion)
+}
+
+// isAuthFailure reports whether a status means the token was rejected
+func isAuthFailure(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// errorMessage extracts an error message from an Anthropic or OpenAI style
+// error body, falling back to the HTTP status text
+func errorMessage(status int, body []byte) string {
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error.Message != "" {
+		return fmt.Sprintf("%d: %s", status, parsed.Error.Message)
+	}
+	return fmt.Sprintf("%d: %s", status, http.StatusText(status))
+}
+
+// configuredModel is a distinct model with the provider fields that use it
+type configuredModel struct {
+	Model string
+	Roles []string
+}
+
+// configuredModels returns the provider's distinct non-empty models in
+// main, sonnet, opus, haiku, small order
+func configuredModels(p *config.Provider) []configuredModel {
+	fields := []struct {
+		role  string
+		model string
+	}{
+		{"main", p.Model},
+		{"sonnet", p.SonnetModel},
+		{"opus", p.OpusModel},
+		{"haiku", p.HaikuModel},
+		{"small", p.SmallModel},
+	}
+
+	var models []configuredModel
+	index := make(map[string]int)
+	for _, f := range fields {
+		if f.model == "" {
+			continue
+		}
+		if i, ok := index[f.model]; ok {
+			models[i].Roles = append(models[i].Roles, f.role)
+			continue
+		}
+		index[f.model] = len(models)
+		models = append(models, configuredModel{Model: f.model, Roles: []string{f.role}})
+	}
+	return models
+}
@@ -0,0 +1,230 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// standIn is a local provider accepting the token "sk-good" and the models
+// in accepted, rejecting others with a 404
+func standIn(t *testing.T, accepted ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "sk-good" || r.Header.Get("Authorization") != "Bearer sk-good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+			return
+		}
+		if r.Header.Get("anthropic-version") != AnthropicVersion {
+			t.Errorf("anthropic-version = %q", r.Header.Get("anthropic-version"))
+		}
+
+		switch r.URL.Path {
+		case "/v1/models":
+			io.WriteString(w, `{"data":[]}`)
+		case "/v1/messages":
+			var req struct {
+				Model     string `json:"model"`
+				MaxTokens int    `json:"max_tokens"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("invalid messages request: %v", err)
+			}
+			if req.MaxTokens != 1 {
+				t.Errorf("max_tokens = %d, want 1", req.MaxTokens)
+			}
+			for _, m := range accepted {
+				if m == req.Model {
+					io.WriteString(w, `{"type":"message","content":[]}`)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"type":"error","error":{"type":"not_found_error","message":"model: `+req.Model+`"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProbeOK(t *testing.T) {
+	srv := standIn(t, "main-model", "small-model")
+	p := &config.Provider{Alias: "a", Name: "A", BaseURL: srv.URL, APIKey: "sk-good",
+		Model: "main-model", OpusModel: "main-model", SmallModel: "small-model"}
+
+	r := (&Prober{}).Probe(context.Background(), p)
+	if !r.OK || r.Status != http.StatusOK || r.AuthFailed || r.Error != "" {
+		t.Fatalf("result = %+v, want OK", r)
+	}
+	if len(r.Models) != 2 {
+		t.Fatalf("models = %+v, want 2", r.Models)
+	}
+	if m := r.Models[0]; m.Model != "main-model" || !m.Accepted || !reflect.DeepEqual(m.Roles, []string{"main", "opus"}) {
+		t.Errorf("models[0] = %+v", m)
+	}
+	if m := r.Models[1]; m.Model != "small-model" || !m.Accepted || !reflect.DeepEqual(m.Roles, []string{"small"}) {
+		t.Errorf("models[1] = %+v", m)
+	}
+}
+
+func TestProbeAuthFailure(t *testing.T) {
+	srv := standIn(t, "main-model")
+	p := &config.Provider{Alias: "a", BaseURL: srv.URL, APIKey: "sk-bad", Model: "main-model"}
+
+	for _, pr := range []*Prober{{}, {PingOnly: true}} {
+		r := pr.Probe(context.Background(), p)
+		if r.OK || !r.AuthFailed || r.Status != http.StatusUnauthorized {
+			t.Errorf("PingOnly=%v: result = %+v, want auth failure", pr.PingOnly, r)
+		}
+		if r.Error != "401: invalid x-api-key" {
+			t.Errorf("PingOnly=%v: error = %q", pr.PingOnly, r.Error)
+		}
+		if len(r.Models) != 0 {
+			t.Errorf("PingOnly=%v: models probed after auth failure: %+v", pr.PingOnly, r.Models)
+		}
+	}
+}
+
+func TestProbeForbidden(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	r := (&Prober{}).Probe(context.Background(), &config.Provider{BaseURL: srv.URL, APIKey: "sk"})
+	if r.OK || !r.AuthFailed || r.Error != "403: Forbidden" {
+		t.Errorf("result = %+v, want auth failure", r)
+	}
+}
+
+func TestProbeRejectedModel(t *testing.T) {
+	srv := standIn(t, "main-model")
+	p := &config.Provider{Alias: "a", BaseURL: srv.URL, APIKey: "sk-good", Model: "main-model", HaikuModel: "retired-model"}
+
+	r := (&Prober{}).Probe(context.Background(), p)
+	if r.OK || r.AuthFailed || r.Error != "" {
+		t.Fatalf("result = %+v, want a model error only", r)
+	}
+	if len(r.Models) != 2 || !r.Models[0].Accepted {
+		t.Fatalf("models = %+v", r.Models)
+	}
+	m := r.Models[1]
+	if m.Accepted || m.Status != http.StatusNotFound || m.Error != "404: model: retired-model" {
+		t.Errorf("rejected model = %+v", m)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	r := (&Prober{Timeout: 50 * time.Millisecond}).Probe(context.Background(), &config.Provider{BaseURL: srv.URL, APIKey: "sk-good"})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("probe took %v", elapsed)
+	}
+	if r.OK || r.AuthFailed || r.Status != 0 || !strings.Contains(r.Error, "deadline exceeded") {
+		t.Errorf("result = %+v, want a timeout", r)
+	}
+}
+
+func TestProbeOpenAIEndpoints(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	p := &config.Provider{BaseURL: srv.URL + "/v1", APIKey: "sk", APIFormat: config.FormatOpenAI, Model: "gpt"}
+	if r := (&Prober{}).Probe(context.Background(), p); !r.OK {
+		t.Fatalf("result = %+v", r)
+	}
+	if want := []string{"/v1/models", "/v1/chat/completions"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestProbeAllKeepsOrder(t *testing.T) {
+	srv := standIn(t)
+	providers := []config.Provider{
+		{Alias: "good", BaseURL: srv.URL, APIKey: "sk-good"},
+		{Alias: "bad", BaseURL: srv.URL, APIKey: "sk-bad"},
+	}
+
+	results := (&Prober{PingOnly: true}).ProbeAll(context.Background(), providers)
+	if len(results) != 2 || results[0].Alias != "good" || !results[0].OK || results[1].Alias != "bad" || !results[1].AuthFailed {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+// TestResultJSON pins the shape 'ccs test --json' prints
+func TestResultJSON(t *testing.T) {
+	srv := standIn(t, "main-model")
+	p := &config.Provider{Alias: "a", Name: "A", BaseURL: srv.URL, APIKey: "sk-good", Model: "main-model", SmallModel: "gone"}
+
+	results := (&Prober{}).ProbeAll(context.Background(), []config.Provider{*p})
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("decoded %d results", len(decoded))
+	}
+	r := decoded[0]
+
+	wantKeys := []string{"alias", "auth_failed", "base_url", "checked_at", "latency_ms", "models", "name", "ok", "status"}
+	if got := keys(r); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("result keys = %v, want %v", got, wantKeys)
+	}
+	if r["alias"] != "a" || r["ok"] != false || r["status"] != float64(200) {
+		t.Errorf("result = %v", r)
+	}
+
+	models := r["models"].([]interface{})
+	accepted := models[0].(map[string]interface{})
+	if got, want := keys(accepted), []string{"accepted", "latency_ms", "model", "roles", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("accepted model keys = %v, want %v", got, want)
+	}
+	rejected := models[1].(map[string]interface{})
+	if got, want := keys(rejected), []string{"accepted", "error", "latency_ms", "model", "roles", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rejected model keys = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(rejected["roles"], []interface{}{"small"}) {
+		t.Errorf("roles = %v", rejected["roles"])
+	}
+}
+
+// keys returns the sorted keys of a JSON object
+func keys(m map[string]interface{}) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
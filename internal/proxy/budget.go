@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/usage"
+)
+
+// budgetCheckInterval is how long a budget check result is reused
+const budgetCheckInterval = 30 * time.Second
+
+var errOverBudget = errors.New("over budget")
+
+// budgetGuard caches budget checks so the usage store is not scanned on
+// every request
+type budgetGuard struct {
+	mu      sync.Mutex
+	checked map[string]budgetCheck
+}
+
+type budgetCheck struct {
+	at       time.Time
+	exceeded bool
+}
+
+// overBudget reports whether a provider with a hard budget has exceeded it.
+// Providers without a hard budget, or a proxy without a usage store, are
+// never over budget
+func (s *Server) overBudget(p *config.Provider) bool {
+	if s.Usage == nil || p.Budget == nil || !p.Budget.Hard {
+		return false
+	}
+
+	s.budgets.mu.Lock()
+	defer s.budgets.mu.Unlock()
+
+	now := time.Now()
+	if c, ok := s.budgets.checked[p.Alias]; ok && now.Sub(c.at) < budgetCheckInterval {
+		return c.exceeded
+	}
+
+	cfg, err := s.store.get()
+	if err != nil {
+		return false
+	}
+
+	records, err := s.Usage.Load(usage.BudgetStart(now))
+	if err != nil {
+		s.logf("failed to load usage for budget check: %v", err)
+		return false
+	}
+
+	exceeded := usage.AnyExceeded(usage.CheckBudget(p.Alias, p.Budget, records, cfg.Prices, now))
+	if s.budgets.checked == nil {
+		s.budgets.checked = make(map[string]budgetCheck)
+	}
+	s.budgets.checked[p.Alias] = budgetCheck{at: now, exceeded: exceeded}
+	return exceeded
+}
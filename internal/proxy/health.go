@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// breakerThreshold is the number of consecutive failures that opens a breaker
+	breakerThreshold = 3
+	// breakerCooldown is how long an open breaker rejects requests before
+	// letting a single trial request through
+	breakerCooldown = 30 * time.Second
+)
+
+// Breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// ProviderHealth is the proxy's view of one provider, as reported by the
+// status endpoint
+type ProviderHealth struct {
+	Alias       string    `json:"alias"`
+	State       string    `json:"state"`
+	Requests    int       `json:"requests"`
+	Failures    int       `json:"failures"`
+	Consecutive int       `json:"consecutive_failures"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	OpenUntil   time.Time `json:"open_until,omitempty"`
+}
+
+// Status is the body of the proxy status endpoint
+type Status struct {
+	StartedAt time.Time        `json:"started_at"`
+	Current   string           `json:"current"`
+	Providers []ProviderHealth `json:"providers"`
+}
+
+// healthTracker keeps per-provider circuit breakers and request counters
+type healthTracker struct {
+	mu        sync.Mutex
+	providers map[string]*ProviderHealth
+	trial     map[string]bool // Half-open breakers with a trial request in flight
+}
+
+// get returns the health entry for alias, creating it if needed.
+// The caller must hold mu
+func (h *healthTracker) get(alias string) *ProviderHealth {
+	if h.providers == nil {
+		h.providers = make(map[string]*ProviderHealth)
+		h.trial = make(map[string]bool)
+	}
+	ph, ok := h.providers[alias]
+	if !ok {
+		ph = &ProviderHealth{Alias: alias, State: StateClosed}
+		h.providers[alias] = ph
+	}
+	return ph
+}
+
+// allow reports whether a request may be sent to alias. An open breaker
+// whose cooldown has passed lets one trial request through
+func (h *healthTracker) allow(alias string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ph := h.get(alias)
+	switch ph.State {
+	case StateOpen:
+		if time.Now().Before(ph.OpenUntil) {
+			return false
+		}
+		ph.State = StateHalfOpen
+		h.trial[alias] = true
+		return true
+	case StateHalfOpen:
+		if h.trial[alias] {
+			return false
+		}
+		h.trial[alias] = true
+		return true
+	}
+	return true
+}
+
+// success records a successful request and closes the breaker
+func (h *healthTracker) success(alias string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ph := h.get(alias)
+	ph.Requests++
+	ph.Consecutive = 0
+	ph.LastSuccess = time.Now()
+	ph.State = StateClosed
+	ph.OpenUntil = time.Time{}
+	h.trial[alias] = false
+}
+
+// failure records a failed request, opening the breaker after too many
+// consecutive failures or a failed trial request
+func (h *healthTracker) failure(alias, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ph := h.get(alias)
+	ph.Requests++
+	ph.Failures++
+	ph.Consecutive++
+	ph.LastError = reason
+	ph.LastFailure = time.Now()
+
+	if ph.State == StateHalfOpen || ph.Consecutive >= breakerThreshold {
+		ph.State = StateOpen
+		ph.OpenUntil = time.Now().Add(breakerCooldown)
+	}
+	h.trial[alias] = false
+}
+
+// snapshot returns a copy of all health entries sorted by alias
+func (h *healthTracker) snapshot() []ProviderHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := make([]ProviderHealth, 0, len(h.providers))
+	for _, ph := range h.providers {
+		list = append(list, *ph)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Alias < list[j].Alias
+	})
+	return list
+}
+
+// isRetryable reports whether a status should fail over to the next provider
+func isRetryable(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusRequestTimeout ||
+		status >= 500
+}
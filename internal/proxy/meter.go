@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/usage"
+)
+
+// messagesPath is the Messages API path whose responses carry token usage
+const messagesPath = "/v1/messages"
+
+// meter wraps a successful Messages API response body to extract token
+// usage while it is relayed. The returned function records the usage once
+// the body has been read; it is a no-op when metering does not apply
+func (s *Server) meter(resp *http.Response, path string, p *config.Provider, reqBody []byte) func() {
+	if s.Usage == nil || path != messagesPath || resp.StatusCode != http.StatusOK {
+		return func() {}
+	}
+
+	stream := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	m := usage.NewMeter(resp.Body, stream)
+	resp.Body = m
+
+	return func() {
+		rec, ok := m.Result()
+		if !ok {
+			return
+		}
+		rec.Time = time.Now()
+		rec.Provider = p.Alias
+		if rec.Model == "" {
+			rec.Model = requestModel(reqBody)
+		}
+		if err := s.Usage.Append(rec); err != nil {
+			s.logf("failed to record usage: %v", err)
+		}
+	}
+}
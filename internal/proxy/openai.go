@@ -0,0 +1,794 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// countTokensPath is the Messages API token counting path, which
+// OpenAI-format providers lack and the proxy estimates locally
+const countTokensPath = "/v1/messages/count_tokens"
+
+// anthropicRequest is the part of a Messages API request that is translated
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        json.RawMessage    `json:"system"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature"`
+	TopP          *float64           `json:"top_p"`
+	StopSequences []string           `json:"stop_sequences"`
+	Stream        bool               `json:"stream"`
+	Tools         []anthropicTool    `json:"tools"`
+	ToolChoice    *struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"tool_choice"`
+}
+
+// anthropicMessage is a message whose content is a string or a block list
+type anthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// contentBlock is a Messages API content block
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	Source    *struct {
+		Type      string `json:"type"`
+		MediaType string `json:"media_type"`
+		Data      string `json:"data"`
+		URL       string `json:"url"`
+	} `json:"source,omitempty"`
+}
+
+// anthropicTool is a client tool definition
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// openAIMessage is a Chat Completions message. Content is a string, a part
+// list or nil
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openAIToolCall is a function call made by the assistant
+type openAIToolCall struct {
+	Index    *int   `json:"index,omitempty"` // Set in stream deltas only
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIUsage is the usage object of a Chat Completions response
+type openAIUsage struct {
+	PromptTokens        int64 `json:"prompt_tokens"`
+	CompletionTokens    int64 `json:"completion_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int64 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// anthropicUsage converts usage, reporting cached prompt tokens as cache reads
+func (u *openAIUsage) anthropicUsage() map[string]int64 {
+	var cached int64
+	if u.PromptTokensDetails != nil {
+		cached = u.PromptTokensDetails.CachedTokens
+	}
+	return map[string]int64{
+		"input_tokens":            u.PromptTokens - cached,
+		"output_tokens":           u.CompletionTokens,
+		"cache_read_input_tokens": cached,
+	}
+}
+
+// toOpenAIRequest translates a Messages API request body into a Chat
+// Completions request body, reporting whether a stream was requested
+func toOpenAIRequest(body []byte) ([]byte, bool, error) {
+	var req anthropicRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false, fmt.Errorf("invalid request body: %v", err)
+	}
+
+	var messages []openAIMessage
+	system, err := joinText(req.System)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid system prompt: %v", err)
+	}
+	if system != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: system})
+	}
+
+	for _, m := range req.Messages {
+		blocks, err := parseContent(m.Content)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s message: %v", m.Role, err)
+		}
+		if m.Role == "assistant" {
+			messages = append(messages, assistantMessage(blocks))
+		} else {
+			messages = append(messages, userMessages(blocks)...)
+		}
+	}
+
+	out := map[string]interface{}{
+		"model":    req.Model,
+		"messages": messages,
+	}
+	if req.MaxTokens > 0 {
+		out["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		out["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		out["top_p"] = *req.TopP
+	}
+	if len(req.StopSequences) > 0 {
+		out["stop"] = req.StopSequences
+	}
+	if req.Stream {
+		out["stream"] = true
+		out["stream_options"] = map[string]bool{"include_usage": true}
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, t := range req.Tools {
+			schema := t.InputSchema
+			if len(schema) == 0 {
+				schema = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			tools = append(tools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        t.Name,
+					"description": t.Description,
+					"parameters":  schema,
+				},
+			})
+		}
+		out["tools"] = tools
+	}
+	if req.ToolChoice != nil {
+		switch req.ToolChoice.Type {
+		case "auto":
+			out["tool_choice"] = "auto"
+		case "any":
+			out["tool_choice"] = "required"
+		case "none":
+			out["tool_choice"] = "none"
+		case "tool":
+			out["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": req.ToolChoice.Name},
+			}
+		}
+	}
+
+	data, err := json.Marshal(out)
+	return data, req.Stream, err
+}
+
+// parseContent parses message content given as a string or a block list
+func parseContent(raw json.RawMessage) ([]contentBlock, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return []contentBlock{{Type: "text", Text: text}}, nil
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// joinText joins the text blocks of content given as a string or a block list
+func joinText(raw json.RawMessage) (string, error) {
+	blocks, err := parseContent(raw)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// assistantMessage translates an assistant turn. Text blocks become the
+// content and tool_use blocks become tool calls; thinking blocks are dropped
+func assistantMessage(blocks []contentBlock) openAIMessage {
+	msg := openAIMessage{Role: "assistant"}
+
+	var text []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			text = append(text, b.Text)
+		case "tool_use":
+			call := openAIToolCall{ID: b.ID, Type: "function"}
+			call.Function.Name = b.Name
+			call.Function.Arguments = string(b.Input)
+			if call.Function.Arguments == "" {
+				call.Function.Arguments = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+	}
+
+	if len(text) > 0 {
+		msg.Content = strings.Join(text, "")
+	}
+	return msg
+}
+
+// userMessages translates a user turn. Each tool_result block becomes a
+// tool message, which must directly follow the assistant's tool calls, and
+// the remaining text and image blocks become one user message
+func userMessages(blocks []contentBlock) []openAIMessage {
+	var messages []openAIMessage
+	var parts []map[string]interface{}
+	hasImage := false
+
+	for _, b := range blocks {
+		switch b.Type {
+		case "tool_result":
+			result, _ := joinText(b.Content)
+			if b.IsError {
+				result = "Error: " + result
+			}
+			messages = append(messages, openAIMessage{Role: "tool", Content: result, ToolCallID: b.ToolUseID})
+		case "text":
+			parts = append(parts, map[string]interface{}{"type": "text", "text": b.Text})
+		case "image":
+			if b.Source == nil {
+				continue
+			}
+			url := b.Source.URL
+			if b.Source.Type == "base64" {
+				url = "data:" + b.Source.MediaType + ";base64," + b.Source.Data
+			}
+			parts = append(parts, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]string{"url": url},
+			})
+			hasImage = true
+		}
+	}
+
+	if len(parts) == 0 {
+		return messages
+	}
+
+	// Plain text is sent as a string, which every server accepts
+	if !hasImage {
+		var text []string
+		for _, part := range parts {
+			text = append(text, part["text"].(string))
+		}
+		return append(messages, openAIMessage{Role: "user", Content: strings.Join(text, "\n\n")})
+	}
+	return append(messages, openAIMessage{Role: "user", Content: parts})
+}
+
+// stopReason maps a Chat Completions finish reason to a Messages API stop reason
+func stopReason(finish string) string {
+	switch finish {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
+// errorType maps an HTTP status to a Messages API error type
+func errorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+// errorBody returns an Anthropic-style error body
+func errorBody(status int, message string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type": "error",
+		"error": map[string]string{
+			"type":    errorType(status),
+			"message": message,
+		},
+	})
+	return data
+}
+
+// localResponse builds a JSON response that did not come from a provider
+func localResponse(status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// countTokensResponse estimates the input tokens of a request at roughly
+// four bytes per token, for clients that size their context with it
+func countTokensResponse(body []byte) *http.Response {
+	data, _ := json.Marshal(map[string]int{"input_tokens": len(body)/4 + 1})
+	return localResponse(http.StatusOK, data)
+}
+
+// fromOpenAIResponse translates a Chat Completions response into a Messages
+// API response in place. model names the response if the provider omits it
+func fromOpenAIResponse(resp *http.Response, stream bool, model string) {
+	resp.Header.Del("Content-Length")
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		resp.Header.Set("Content-Type", "application/json")
+		resp.Body = io.NopCloser(bytes.NewReader(errorBody(resp.StatusCode, openAIErrorMessage(resp.StatusCode, data))))
+		return
+	}
+
+	if stream && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		resp.Body = translateStream(resp.Body, model)
+		return
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Header.Set("Content-Type", "application/json")
+	if err == nil {
+		data, err = translateMessage(data, model)
+	}
+	if err != nil {
+		resp.StatusCode = http.StatusBadGateway
+		data = errorBody(resp.StatusCode, fmt.Sprintf("unexpected provider response: %v", err))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	// A streaming client expects events even if the provider did not stream
+	if stream && resp.StatusCode == http.StatusOK {
+		resp.Header.Set("Content-Type", "text/event-stream")
+		resp.Body = io.NopCloser(bytes.NewReader(messageEvents(data)))
+	}
+}
+
+// openAIErrorMessage extracts the message of an OpenAI-style error body
+func openAIErrorMessage(status int, body []byte) string {
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error.Message != "" {
+		return parsed.Error.Message
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return text
+	}
+	return http.StatusText(status)
+}
+
+// translateMessage translates a Chat Completions response body
+func translateMessage(body []byte, model string) ([]byte, error) {
+	var resp struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content   string           `json:"content"`
+				ToolCalls []openAIToolCall `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices")
+	}
+	if resp.Model != "" {
+		model = resp.Model
+	}
+
+	choice := resp.Choices[0]
+	content := []map[string]interface{}{}
+	if choice.Message.Content != "" {
+		content = append(content, map[string]interface{}{"type": "text", "text": choice.Message.Content})
+	}
+	for i, call := range choice.Message.ToolCalls {
+		content = append(content, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    toolUseID(call.ID, i),
+			"name":  call.Function.Name,
+			"input": toolInput(call.Function.Arguments),
+		})
+	}
+
+	usage := map[string]int64{"input_tokens": 0, "output_tokens": 0}
+	if resp.Usage != nil {
+		usage = resp.Usage.anthropicUsage()
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"id":            "msg_" + resp.ID,
+		"type":          "message",
+		"role":          "assistant",
+		"model":         model,
+		"content":       content,
+		"stop_reason":   stopReason(choice.FinishReason),
+		"stop_sequence": nil,
+		"usage":         usage,
+	})
+}
+
+// toolUseID returns a tool call's ID, inventing one if the provider sent none
+func toolUseID(id string, index int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("toolu_%d", index)
+}
+
+// toolInput parses tool call arguments, which must be a JSON object
+func toolInput(arguments string) json.RawMessage {
+	var input map[string]interface{}
+	if json.Unmarshal([]byte(arguments), &input) != nil || input == nil {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// messageEvents renders a complete Messages API response as the events of
+// a stream
+func messageEvents(message []byte) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(message, &msg)
+	content, _ := msg["content"].([]interface{})
+
+	var buf bytes.Buffer
+	start := map[string]interface{}{}
+	for k, v := range msg {
+		start[k] = v
+	}
+	start["content"] = []interface{}{}
+	start["stop_reason"] = nil
+	writeEvent(&buf, "message_start", map[string]interface{}{"type": "message_start", "message": start})
+
+	// Blocks start empty and are filled by deltas, as in a real stream
+	for i, c := range content {
+		block, _ := c.(map[string]interface{})
+		var delta map[string]interface{}
+		switch block["type"] {
+		case "text":
+			delta = map[string]interface{}{"type": "text_delta", "text": block["text"]}
+			block = map[string]interface{}{"type": "text", "text": ""}
+		case "tool_use":
+			input, _ := json.Marshal(block["input"])
+			delta = map[string]interface{}{"type": "input_json_delta", "partial_json": string(input)}
+			block = map[string]interface{}{"type": "tool_use", "id": block["id"], "name": block["name"], "input": map[string]interface{}{}}
+		}
+		writeEvent(&buf, "content_block_start", map[string]interface{}{"type": "content_block_start", "index": i, "content_block": block})
+		if delta != nil {
+			writeEvent(&buf, "content_block_delta", map[string]interface{}{"type": "content_block_delta", "index": i, "delta": delta})
+		}
+		writeEvent(&buf, "content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": i})
+	}
+
+	writeEvent(&buf, "message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": msg["stop_reason"], "stop_sequence": nil},
+		"usage": msg["usage"],
+	})
+	writeEvent(&buf, "message_stop", map[string]string{"type": "message_stop"})
+	return buf.Bytes()
+}
+
+// writeEvent writes one server-sent event
+func writeEvent(w io.Writer, name string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+// translateStream returns a body that yields Messages API events translated
+// from a Chat Completions event stream as it arrives
+func translateStream(src io.ReadCloser, model string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		t := &streamTranslator{w: pw, model: model}
+		pw.CloseWithError(t.run(src))
+	}()
+	return &streamBody{PipeReader: pr, src: src}
+}
+
+// streamBody closes the upstream body along with the translated stream
+type streamBody struct {
+	*io.PipeReader
+	src io.ReadCloser
+}
+
+func (b *streamBody) Close() error {
+	b.PipeReader.Close()
+	return b.src.Close()
+}
+
+// streamTranslator converts Chat Completions chunks into Messages API
+// events. Text and each tool call become consecutive content blocks
+type streamTranslator struct {
+	w     io.Writer
+	model string
+
+	started  bool
+	finished bool
+	index    int    // Index of the next content block
+	open     string // Type of the open content block, "" if none
+	toolCall int    // OpenAI index of the open tool call
+	toolID   string // ID of the open tool call
+	stop     string
+	usage    *openAIUsage
+}
+
+// streamChunk is one Chat Completions stream event
+type streamChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// run translates src until [DONE]. A stream that ends without [DONE] was
+// cut short, so it ends with an error event rather than message_stop
+func (t *streamTranslator) run(src io.Reader) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 64<<10), 4<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return t.finish()
+		}
+
+		var chunk streamChunk
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			return t.fail(chunk.Error.Message)
+		}
+		if err := t.chunk(&chunk); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return t.fail("upstream stream ended before [DONE]")
+}
+
+// fail sends an error event, which ends a Messages API stream
+func (t *streamTranslator) fail(message string) error {
+	return writeEvent(t.w, "error", map[string]interface{}{
+		"type":  "error",
+		"error": map[string]string{"type": "api_error", "message": message},
+	})
+}
+
+// chunk translates one stream event
+func (t *streamTranslator) chunk(c *streamChunk) error {
+	if err := t.start(c); err != nil {
+		return err
+	}
+	if c.Usage != nil {
+		t.usage = c.Usage
+	}
+
+	for _, choice := range c.Choices {
+		if choice.Delta.Content != "" {
+			if t.open != "text" {
+				if err := t.openBlock(map[string]interface{}{"type": "text", "text": ""}); err != nil {
+					return err
+				}
+				t.open = "text"
+			}
+			if err := t.delta(map[string]string{"type": "text_delta", "text": choice.Delta.Content}); err != nil {
+				return err
+			}
+		}
+
+		for _, call := range choice.Delta.ToolCalls {
+			index := 0
+			if call.Index != nil {
+				index = *call.Index
+			}
+			// Providers differ in whether later chunks repeat the call ID,
+			// so only a new index or a different ID starts a new call
+			if t.open != "tool_use" || t.toolCall != index || (call.ID != "" && call.ID != t.toolID) {
+				err := t.openBlock(map[string]interface{}{
+					"type":  "tool_use",
+					"id":    toolUseID(call.ID, t.index),
+					"name":  call.Function.Name,
+					"input": map[string]interface{}{},
+				})
+				if err != nil {
+					return err
+				}
+				t.open, t.toolCall, t.toolID = "tool_use", index, call.ID
+			}
+			if call.Function.Arguments != "" {
+				if err := t.delta(map[string]string{"type": "input_json_delta", "partial_json": call.Function.Arguments}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if choice.FinishReason != "" {
+			t.stop = stopReason(choice.FinishReason)
+		}
+	}
+	return nil
+}
+
+// start sends message_start before the first event
+func (t *streamTranslator) start(c *streamChunk) error {
+	if t.started {
+		return nil
+	}
+	t.started = true
+
+	id, model := "", t.model
+	if c != nil {
+		id = c.ID
+		if c.Model != "" {
+			model = c.Model
+		}
+	}
+	return writeEvent(t.w, "message_start", map[string]interface{}{
+		"type": "message_start",
+		"message": map[string]interface{}{
+			"id":            "msg_" + id,
+			"type":          "message",
+			"role":          "assistant",
+			"model":         model,
+			"content":       []interface{}{},
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage":         map[string]int64{"input_tokens": 0, "output_tokens": 0},
+		},
+	})
+}
+
+// openBlock closes the open content block and starts a new one
+func (t *streamTranslator) openBlock(block map[string]interface{}) error {
+	if err := t.closeBlock(); err != nil {
+		return err
+	}
+	return writeEvent(t.w, "content_block_start", map[string]interface{}{
+		"type":          "content_block_start",
+		"index":         t.index,
+		"content_block": block,
+	})
+}
+
+// delta sends a delta for the open content block
+func (t *streamTranslator) delta(delta map[string]string) error {
+	return writeEvent(t.w, "content_block_delta", map[string]interface{}{
+		"type":  "content_block_delta",
+		"index": t.index,
+		"delta": delta,
+	})
+}
+
+// closeBlock closes the open content block, if any
+func (t *streamTranslator) closeBlock() error {
+	if t.open == "" {
+		return nil
+	}
+	err := writeEvent(t.w, "content_block_stop", map[string]interface{}{
+		"type":  "content_block_stop",
+		"index": t.index,
+	})
+	t.open = ""
+	t.index++
+	return err
+}
+
+// finish closes the open block and sends message_delta and message_stop.
+// The final usage is sent with message_delta, since OpenAI reports it last
+func (t *streamTranslator) finish() error {
+	if t.finished {
+		return nil
+	}
+	t.finished = true
+
+	if err := t.start(nil); err != nil {
+		return err
+	}
+	if err := t.closeBlock(); err != nil {
+		return err
+	}
+
+	if t.stop == "" {
+		t.stop = "end_turn"
+	}
+	usage := map[string]int64{"output_tokens": 0}
+	if t.usage != nil {
+		usage = t.usage.anthropicUsage()
+	}
+	err := writeEvent(t.w, "message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": t.stop, "stop_sequence": nil},
+		"usage": usage,
+	})
+	if err != nil {
+		return err
+	}
+	return writeEvent(t.w, "message_stop", map[string]string{"type": "message_stop"})
+}
@@ -0,0 +1,401 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// jsonValue parses a JSON literal for comparisons
+func jsonValue(t *testing.T, data string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return v
+}
+
+func TestToOpenAIRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		want   string
+		stream bool
+	}{
+		{
+			name: "system prompt string",
+			in:   `{"model":"m","max_tokens":100,"system":"Be brief.","messages":[{"role":"user","content":"Hi"}]}`,
+			want: `{"model":"m","max_tokens":100,"messages":[
+				{"role":"system","content":"Be brief."},
+				{"role":"user","content":"Hi"}]}`,
+		},
+		{
+			name: "system prompt blocks",
+			in: `{"model":"m","system":[{"type":"text","text":"One."},{"type":"text","text":"Two.","cache_control":{"type":"ephemeral"}}],
+				"messages":[{"role":"user","content":[{"type":"text","text":"A"},{"type":"text","text":"B"}]}]}`,
+			want: `{"model":"m","messages":[
+				{"role":"system","content":"One.\n\nTwo."},
+				{"role":"user","content":"A\n\nB"}]}`,
+		},
+		{
+			name: "tool use and tool result",
+			in: `{"model":"m","messages":[
+				{"role":"user","content":"Weather in Paris?"},
+				{"role":"assistant","content":[
+					{"type":"thinking","thinking":"..."},
+					{"type":"text","text":"Checking."},
+					{"type":"tool_use","id":"toolu_1","name":"weather","input":{"city":"Paris"}},
+					{"type":"tool_use","id":"toolu_2","name":"time","input":{}}]},
+				{"role":"user","content":[
+					{"type":"tool_result","tool_use_id":"toolu_1","content":"18C"},
+					{"type":"tool_result","tool_use_id":"toolu_2","content":[{"type":"text","text":"timeout"}],"is_error":true},
+					{"type":"text","text":"Thanks"}]}],
+				"tools":[{"name":"weather","description":"Get weather","input_schema":{"type":"object","properties":{"city":{"type":"string"}}}},{"name":"time"}],
+				"tool_choice":{"type":"tool","name":"weather"}}`,
+			want: `{"model":"m","messages":[
+				{"role":"user","content":"Weather in Paris?"},
+				{"role":"assistant","content":"Checking.","tool_calls":[
+					{"id":"toolu_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}},
+					{"id":"toolu_2","type":"function","function":{"name":"time","arguments":"{}"}}]},
+				{"role":"tool","content":"18C","tool_call_id":"toolu_1"},
+				{"role":"tool","content":"Error: timeout","tool_call_id":"toolu_2"},
+				{"role":"user","content":"Thanks"}],
+				"tools":[
+					{"type":"function","function":{"name":"weather","description":"Get weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}},
+					{"type":"function","function":{"name":"time","description":"","parameters":{"type":"object","properties":{}}}}],
+				"tool_choice":{"type":"function","function":{"name":"weather"}}}`,
+		},
+		{
+			name: "assistant tool call without text",
+			in: `{"model":"m","messages":[{"role":"assistant","content":[{"type":"tool_use","id":"t","name":"f","input":{"a":1}}]}],
+				"tool_choice":{"type":"any"}}`,
+			want: `{"model":"m","messages":[
+				{"role":"assistant","content":null,"tool_calls":[{"id":"t","type":"function","function":{"name":"f","arguments":"{\"a\":1}"}}]}],
+				"tool_choice":"required"}`,
+		},
+		{
+			name: "images",
+			in: `{"model":"m","messages":[{"role":"user","content":[
+				{"type":"text","text":"What is this?"},
+				{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0"}},
+				{"type":"image","source":{"type":"url","url":"https://example.com/cat.jpg"}}]}]}`,
+			want: `{"model":"m","messages":[{"role":"user","content":[
+				{"type":"text","text":"What is this?"},
+				{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0"}},
+				{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}]}]}`,
+		},
+		{
+			name: "stop sequences and sampling",
+			in: `{"model":"m","max_tokens":10,"temperature":0.2,"top_p":0.9,"stop_sequences":["END","\n\nHuman:"],
+				"messages":[{"role":"user","content":"Hi"}],"tool_choice":{"type":"none"}}`,
+			want: `{"model":"m","max_tokens":10,"temperature":0.2,"top_p":0.9,"stop":["END","\n\nHuman:"],
+				"messages":[{"role":"user","content":"Hi"}],"tool_choice":"none"}`,
+		},
+		{
+			name:   "stream asks for usage",
+			in:     `{"model":"m","stream":true,"messages":[{"role":"user","content":"Hi"}],"tool_choice":{"type":"auto"}}`,
+			want:   `{"model":"m","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hi"}],"tool_choice":"auto"}`,
+			stream: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stream, err := toOpenAIRequest([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("toOpenAIRequest: %v", err)
+			}
+			if stream != tt.stream {
+				t.Errorf("stream = %v, want %v", stream, tt.stream)
+			}
+			if g, w := jsonValue(t, string(got)), jsonValue(t, tt.want); !reflect.DeepEqual(g, w) {
+				t.Errorf("request =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToOpenAIRequestInvalid(t *testing.T) {
+	for _, in := range []string{
+		`not json`,
+		`{"model":"m","system":42,"messages":[]}`,
+		`{"model":"m","messages":[{"role":"user","content":42}]}`,
+	} {
+		if _, _, err := toOpenAIRequest([]byte(in)); err == nil {
+			t.Errorf("toOpenAIRequest(%s) succeeded", in)
+		}
+	}
+}
+
+func TestTranslateMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "text with cached usage",
+			in: `{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],
+				"usage":{"prompt_tokens":100,"completion_tokens":5,"prompt_tokens_details":{"cached_tokens":60}}}`,
+			want: `{"id":"msg_chatcmpl-1","type":"message","role":"assistant","model":"gpt-4o",
+				"content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","stop_sequence":null,
+				"usage":{"input_tokens":40,"output_tokens":5,"cache_read_input_tokens":60}}`,
+		},
+		{
+			name: "tool calls",
+			in: `{"id":"c2","choices":[{"message":{"role":"assistant","content":null,"tool_calls":[
+				{"id":"call_a","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}},
+				{"type":"function","function":{"name":"broken","arguments":"not json"}}]},"finish_reason":"tool_calls"}]}`,
+			want: `{"id":"msg_c2","type":"message","role":"assistant","model":"requested",
+				"content":[
+					{"type":"tool_use","id":"call_a","name":"weather","input":{"city":"Paris"}},
+					{"type":"tool_use","id":"toolu_1","name":"broken","input":{}}],
+				"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`,
+		},
+		{
+			name: "length",
+			in:   `{"id":"c3","choices":[{"message":{"content":"Cut"},"finish_reason":"length"}]}`,
+			want: `{"id":"msg_c3","type":"message","role":"assistant","model":"requested",
+				"content":[{"type":"text","text":"Cut"}],"stop_reason":"max_tokens","stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := translateMessage([]byte(tt.in), "requested")
+			if err != nil {
+				t.Fatalf("translateMessage: %v", err)
+			}
+			if g, w := jsonValue(t, string(got)), jsonValue(t, tt.want); !reflect.DeepEqual(g, w) {
+				t.Errorf("message =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := translateMessage([]byte(`{"choices":[]}`), "m"); err == nil {
+		t.Error("translateMessage without choices succeeded")
+	}
+}
+
+func TestFromOpenAIResponseError(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Content-Type": {"application/json"}, "Content-Length": {"64"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"Rate limit reached","type":"requests"}}`)),
+	}
+	fromOpenAIResponse(resp, false, "m")
+
+	body, _ := io.ReadAll(resp.Body)
+	want := `{"type":"error","error":{"type":"rate_limit_error","message":"Rate limit reached"}}`
+	if g, w := jsonValue(t, string(body)), jsonValue(t, want); !reflect.DeepEqual(g, w) {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+	if resp.Header.Get("Content-Length") != "" {
+		t.Error("stale Content-Length kept")
+	}
+}
+
+// sseEvent is one parsed server-sent event
+type sseEvent struct {
+	Name string
+	Data map[string]interface{}
+}
+
+// parseEvents parses a Messages API event stream
+func parseEvents(t *testing.T, stream []byte) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, raw := range strings.Split(strings.TrimSpace(string(stream)), "\n\n") {
+		var e sseEvent
+		for _, line := range strings.Split(raw, "\n") {
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				e.Name = name
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+					t.Fatalf("invalid event data %s: %v", data, err)
+				}
+			}
+		}
+		if e.Name != e.Data["type"] {
+			t.Errorf("event %q has type %v", e.Name, e.Data["type"])
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// eventNames returns the names of events
+func eventNames(events []sseEvent) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// translate runs a recorded Chat Completions stream through translateStream
+func translate(t *testing.T, stream string) []sseEvent {
+	t.Helper()
+	body := translateStream(io.NopCloser(strings.NewReader(stream)), "requested")
+	defer body.Close()
+	out, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("translateStream: %v", err)
+	}
+	return parseEvents(t, out)
+}
+
+// recordedToolStream is a Chat Completions stream with text, two tool calls
+// whose arguments arrive in pieces, and usage in a final chunk
+const recordedToolStream = `data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Let me "},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"check."},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_w","type":"function","function":{"name":"weather","arguments":""}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_t","type":"function","function":{"name":"time","arguments":"{}"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: {"id":"chatcmpl-9","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":50,"completion_tokens":20,"prompt_tokens_details":{"cached_tokens":10}}}
+
+data: [DONE]
+
+`
+
+func TestTranslateStreamToolCalls(t *testing.T) {
+	events := translate(t, recordedToolStream)
+
+	wantNames := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if got := eventNames(events); !reflect.DeepEqual(got, wantNames) {
+		t.Fatalf("events = %v, want %v", got, wantNames)
+	}
+
+	want := []string{
+		`{"type":"message_start","message":{"id":"msg_chatcmpl-9","type":"message","role":"assistant","model":"gpt-4o",
+			"content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"check."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"call_w","name":"weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"call_t","name":"time","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},
+			"usage":{"input_tokens":40,"output_tokens":20,"cache_read_input_tokens":10}}`,
+		`{"type":"message_stop"}`,
+	}
+	for i, w := range want {
+		if got := interface{}(events[i].Data); !reflect.DeepEqual(got, jsonValue(t, w)) {
+			t.Errorf("event %d = %v, want %s", i, got, w)
+		}
+	}
+}
+
+func TestTranslateStreamText(t *testing.T) {
+	// No usage: [DONE] finishes the message with zero output tokens
+	events := translate(t, `data: {"id":"c","choices":[{"delta":{"content":"Hi"},"finish_reason":null}]}
+
+: keep-alive
+
+data: {"id":"c","choices":[{"delta":{},"finish_reason":"length"}]}
+
+data: [DONE]
+`)
+
+	wantNames := []string{"message_start", "content_block_start", "content_block_delta", "content_block_stop", "message_delta", "message_stop"}
+	if got := eventNames(events); !reflect.DeepEqual(got, wantNames) {
+		t.Fatalf("events = %v, want %v", got, wantNames)
+	}
+	if model := events[0].Data["message"].(map[string]interface{})["model"]; model != "requested" {
+		t.Errorf("model = %v, want the requested model", model)
+	}
+	delta := events[4].Data
+	if got, want := interface{}(delta), jsonValue(t, `{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"output_tokens":0}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("message_delta = %v, want %v", got, want)
+	}
+}
+
+func TestTranslateStreamTruncated(t *testing.T) {
+	// The connection closing before [DONE] must not look like a complete message
+	events := translate(t, `data: {"id":"c","choices":[{"delta":{"content":"Hi"},"finish_reason":null}]}
+
+data: {"id":"c","choices":[{"delta":{},"finish_reason":"stop"}]}
+`)
+
+	wantNames := []string{"message_start", "content_block_start", "content_block_delta", "error"}
+	if got := eventNames(events); !reflect.DeepEqual(got, wantNames) {
+		t.Fatalf("events = %v, want %v", got, wantNames)
+	}
+	if got, want := interface{}(events[3].Data), jsonValue(t, `{"type":"error","error":{"type":"api_error","message":"upstream stream ended before [DONE]"}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("error = %v, want %v", got, want)
+	}
+}
+
+func TestTranslateStreamError(t *testing.T) {
+	events := translate(t, `data: {"error":{"message":"upstream overloaded"}}
+`)
+
+	if len(events) != 1 || events[0].Name != "error" {
+		t.Fatalf("events = %v, want one error", eventNames(events))
+	}
+	if got, want := interface{}(events[0].Data), jsonValue(t, `{"type":"error","error":{"type":"api_error","message":"upstream overloaded"}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("error = %v, want %v", got, want)
+	}
+}
+
+func TestFromOpenAIResponseStreamFallback(t *testing.T) {
+	// A provider that ignores stream:true answers with a single JSON body
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body: io.NopCloser(bytes.NewReader([]byte(`{"id":"c","model":"gpt-4o","choices":[{"message":{"content":"Hi","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"a\":1}"}}]},"finish_reason":"tool_calls"}],
+			"usage":{"prompt_tokens":3,"completion_tokens":4}}`))),
+	}
+	fromOpenAIResponse(resp, true, "m")
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	out, _ := io.ReadAll(resp.Body)
+	events := parseEvents(t, out)
+
+	wantNames := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if got := eventNames(events); !reflect.DeepEqual(got, wantNames) {
+		t.Fatalf("events = %v, want %v", got, wantNames)
+	}
+	if got, want := interface{}(events[5].Data), jsonValue(t, `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"a\":1}"}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("tool delta = %v, want %v", got, want)
+	}
+	if got, want := interface{}(events[7].Data), jsonValue(t, `{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},
+		"usage":{"input_tokens":3,"output_tokens":4,"cache_read_input_tokens":0}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("message_delta = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,550 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/capture"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/probe"
+	"github.com/katz/ccs/internal/usage"
+)
+
+// defaultHeaderTimeout bounds the wait for response headers for providers
+// without a configured timeout
+const defaultHeaderTimeout = 5 * time.Minute
+
+// hopHeaders are hop-by-hop headers that must not be forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// StatusPath is the proxy endpoint reporting provider health
+const StatusPath = "/_ccs/status"
+
+// chainPrefix is the path prefix of failover chain endpoints
+const chainPrefix = "/chain/"
+
+// ProviderPrefix is the path prefix of endpoints serving one provider by
+// alias, without model mapping
+const ProviderPrefix = "/p/"
+
+// Server is a local HTTP proxy that forwards Claude Code requests to the
+// provider currently selected in config.json, or through a failover chain
+// for requests under /chain/<alias>, through a model router for requests
+// under /route/<alias>, or to one provider for requests under /p/<alias>.
+// Requests to OpenAI-format providers are translated (see openai.go)
+type Server struct {
+	Client  *http.Client      // HTTP client for upstream requests, http.DefaultClient if nil
+	Logger  *log.Logger       // Request log, discarded if nil
+	Usage   *usage.Store      // Token usage store, usage is not recorded if nil
+	Capture *capture.Recorder // Records request/response pairs, nothing is captured if nil
+
+	AllowRemote bool // Accept requests whose Host is not loopback
+
+	store     configStore
+	health    healthTracker
+	budgets   budgetGuard
+	startOnce sync.Once
+	startedAt time.Time
+}
+
+// ServeHTTP routes a request to the status endpoint, a chain, a router, a
+// provider or the current provider. Requests without the proxy token, or
+// with a Host that is not loopback such as a DNS rebinding page's, are
+// refused unless AllowRemote is set
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.startOnce.Do(func() {
+		s.startedAt = time.Now()
+	})
+
+	if !s.AllowRemote && !config.IsLoopback(r.Host) {
+		s.logf("%s %s: refused, Host %q is not loopback", r.Method, r.URL.Path, r.Host)
+		writeStatusError(w, http.StatusForbidden, "the proxy only accepts loopback hosts, run it with --allow-remote")
+		return
+	}
+
+	cfg, err := s.store.get()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to load ccs config: %v", err))
+		return
+	}
+
+	if !authorized(r, cfg.Proxy.Token) {
+		s.logf("%s %s: refused, missing proxy token", r.Method, r.URL.Path)
+		writeStatusError(w, http.StatusUnauthorized, "invalid proxy token, use the token from 'ccs proxy token' as the API key")
+		return
+	}
+
+	switch {
+	case r.URL.Path == StatusPath:
+		s.serveStatus(w, cfg)
+	case strings.HasPrefix(r.URL.Path, chainPrefix):
+		s.serveChain(w, r, cfg)
+	case strings.HasPrefix(r.URL.Path, routePrefix):
+		s.serveRoute(w, r, cfg)
+	case strings.HasPrefix(r.URL.Path, ProviderPrefix):
+		s.serveProvider(w, r, cfg)
+	default:
+		s.serveCurrent(w, r, cfg)
+	}
+}
+
+// authorized reports whether a request carries the proxy token as its
+// x-api-key or bearer token, so other programs that can reach the proxy
+// cannot spend the providers' keys
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	match := func(v string) bool {
+		return subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return match(r.Header.Get("x-api-key")) || ok && match(bearer)
+}
+
+// serveCurrent forwards a request to the current provider
+func (s *Server) serveCurrent(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	p, err := cfg.GetCurrentProvider()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "no current ccs provider, run 'ccs use'")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request: %v", err))
+		return
+	}
+
+	s.forward(w, r, r.URL.Path, body, []*config.Provider{p}, true)
+}
+
+// serveProvider forwards a request under /p/<alias>/ to that provider. The
+// model is not mapped, since clients of this endpoint use the provider's
+// own model names
+func (s *Server) serveProvider(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	rest := strings.TrimPrefix(r.URL.Path, ProviderPrefix)
+	alias, path, _ := strings.Cut(rest, "/")
+	path = "/" + path
+
+	p, err := s.provider(cfg, alias)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("provider '%s' not found", alias))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request: %v", err))
+		return
+	}
+
+	s.forward(w, r, path, body, []*config.Provider{p}, false)
+}
+
+// provider looks up a provider by alias, following the redirect left by
+// 'ccs rename --keep-old' with a deprecation warning
+func (s *Server) provider(cfg *config.Config, alias string) (*config.Provider, error) {
+	if resolved, ok := cfg.ResolveRedirect(alias); ok {
+		s.logf("'%s' was renamed to '%s', the old alias is deprecated", alias, resolved)
+		alias = resolved
+	}
+	return cfg.GetProvider(alias)
+}
+
+// serveChain forwards a request under /chain/<alias>/ through a failover chain
+func (s *Server) serveChain(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	rest := strings.TrimPrefix(r.URL.Path, chainPrefix)
+	alias, path, _ := strings.Cut(rest, "/")
+	path = "/" + path
+
+	chain, err := cfg.GetChain(alias)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chain '%s' not found", alias))
+		return
+	}
+
+	var providers []*config.Provider
+	for _, a := range chain.Providers {
+		p, err := s.provider(cfg, a)
+		if err != nil {
+			s.logf("chain %s: provider '%s' not found, skipping", alias, a)
+			continue
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("chain '%s' has no usable providers", alias))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request: %v", err))
+		return
+	}
+
+	s.forward(w, r, path, body, providers, true)
+}
+
+// forward sends a request to the first provider that answers without a
+// retryable failure (429, 5xx or timeout). Providers with an open circuit
+// breaker are skipped unless every breaker is open, and providers over a
+// hard budget are always skipped. Once a response is relayed to the client
+// no further provider is tried, so a failure in the middle of a stream is
+// not retried. With rewrite set the requested model is
+// mapped onto each provider's models (see MapModel)
+func (s *Server) forward(w http.ResponseWriter, r *http.Request, path string, body []byte, providers []*config.Provider, rewrite bool) {
+	start := time.Now()
+
+	var lastResp *http.Response
+	var lastProvider *config.Provider
+	var lastErr error
+
+	// usable reports whether a provider is within its budget
+	usable := func(p *config.Provider) bool {
+		if s.overBudget(p) {
+			s.logf("%s %s -> %s: skipped, over budget", r.Method, path, p.Alias)
+			lastErr = fmt.Errorf("provider '%s' is %w", p.Alias, errOverBudget)
+			return false
+		}
+		return true
+	}
+
+	// attempt tries one provider and reports whether the response was relayed
+	attempt := func(p *config.Provider) bool {
+		reqBody := body
+		if rewrite {
+			reqBody = rewriteModel(body, p)
+		}
+
+		resp, err := s.send(r, path, reqBody, p)
+		if err != nil {
+			s.health.failure(p.Alias, err.Error())
+			s.logf("%s %s -> %s: %v", r.Method, path, p.Alias, err)
+			lastErr = err
+			return false
+		}
+
+		if isRetryable(resp.StatusCode) {
+			s.health.failure(p.Alias, fmt.Sprintf("HTTP %d", resp.StatusCode))
+			s.logf("%s %s -> %s %d", r.Method, path, p.Alias, resp.StatusCode)
+			// Keep the latest error response so the client can see it if
+			// every provider fails
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			lastResp, lastProvider = resp, p
+			return false
+		}
+
+		record := s.meter(resp, path, p, reqBody)
+		if err := s.relay(w, resp); err != nil {
+			s.health.failure(p.Alias, err.Error())
+		} else {
+			s.health.success(p.Alias)
+		}
+		resp.Body.Close()
+		record()
+		s.logf("%s %s -> %s %d %dms", r.Method, path, p.Alias, resp.StatusCode, time.Since(start).Milliseconds())
+		return true
+	}
+
+	attempted := false
+	for _, p := range providers {
+		if !usable(p) || !s.health.allow(p.Alias) {
+			continue
+		}
+		attempted = true
+		if attempt(p) {
+			return
+		}
+	}
+
+	// Every breaker is open, so try the providers anyway rather than fail outright
+	if !attempted {
+		for _, p := range providers {
+			if !s.overBudget(p) && attempt(p) {
+				return
+			}
+		}
+	}
+
+	if lastResp != nil {
+		s.relay(w, lastResp)
+		lastResp.Body.Close()
+		s.logf("%s %s -> %s %d %dms", r.Method, path, lastProvider.Alias, lastResp.StatusCode, time.Since(start).Milliseconds())
+		return
+	}
+	status := http.StatusBadGateway
+	if errors.Is(lastErr, errOverBudget) {
+		status = http.StatusTooManyRequests
+	}
+	writeError(w, status, fmt.Sprintf("all providers failed, last error: %v", lastErr))
+}
+
+// serveStatus reports provider health as JSON
+func (s *Server) serveStatus(w http.ResponseWriter, cfg *config.Config) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Status{
+		StartedAt: s.startedAt,
+		Current:   cfg.CurrentProvider,
+		Providers: s.health.snapshot(),
+	})
+}
+
+// send forwards a request to a provider, injecting its key. The provider's
+// timeout bounds the wait for response headers but not a streaming body.
+// Messages API requests to OpenAI-format providers are translated both ways
+func (s *Server) send(r *http.Request, path string, body []byte, p *config.Provider) (*http.Response, error) {
+	translate := p.IsOpenAI() && path == messagesPath
+	stream := false
+	if p.IsOpenAI() && path == countTokensPath {
+		return countTokensResponse(body), nil
+	}
+	clientBody := body
+	if translate {
+		translated, streaming, err := toOpenAIRequest(body)
+		if err != nil {
+			return localResponse(http.StatusBadRequest, errorBody(http.StatusBadRequest, err.Error())), nil
+		}
+		body, stream = translated, streaming
+	}
+
+	target := probe.EndpointURL(p, path)
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	req, err := http.NewRequestWithContext(ctx, r.Method, target, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	copyHeaders(req.Header, r.Header)
+	req.Header.Del("Host")
+	req.Header.Del("Content-Length")
+	// Let the transport negotiate compression so bodies can be inspected
+	req.Header.Del("Accept-Encoding")
+	probe.SetAuthHeaders(req.Header, p.APIKey)
+	if v := r.Header.Get("anthropic-version"); v != "" {
+		req.Header.Set("anthropic-version", v)
+	}
+
+	// Captures keep the Messages API form of the exchange so they can be
+	// replayed against any provider
+	captured := s.Capture.Start(p.Alias, r.Method, path, target, req.Header, clientBody)
+
+	timeout := headerTimeout(p)
+	timer := time.AfterFunc(timeout, cancel)
+	resp, err := s.client().Do(req)
+	if !timer.Stop() {
+		// The timeout fired, so even a response that arrived is unusable
+		if err == nil {
+			resp.Body.Close()
+		}
+		err = fmt.Errorf("no response within %s", timeout)
+	}
+	if err != nil {
+		cancel()
+		captured.Fail(err)
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	if translate {
+		fromOpenAIResponse(resp, stream, requestModel(body))
+	}
+	captured.Wrap(resp)
+	return resp, nil
+}
+
+// relay copies an upstream response to the client, flushing as data arrives
+// so server-sent event streams are not buffered. It returns an error if the
+// upstream body failed, but not if the client went away
+func (s *Server) relay(w http.ResponseWriter, resp *http.Response) error {
+	copyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(resp.StatusCode)
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return nil
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// client returns the HTTP client for upstream requests
+func (s *Server) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+// logf writes to the request log if one is configured
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, args...)
+	}
+}
+
+// headerTimeout returns how long to wait for a provider's response headers
+func headerTimeout(p *config.Provider) time.Duration {
+	if p.Timeout > 0 {
+		return time.Duration(p.Timeout) * time.Millisecond
+	}
+	return defaultHeaderTimeout
+}
+
+// cancelBody cancels the upstream request context when the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// copyHeaders copies headers except hop-by-hop and client credentials
+func copyHeaders(dst, src http.Header) {
+	for key, values := range src {
+		if isHopHeader(key) {
+			continue
+		}
+		for _, v := range values {
+			dst.Add(key, v)
+		}
+	}
+	dst.Del("Authorization")
+	dst.Del("X-Api-Key")
+}
+
+func isHopHeader(key string) bool {
+	for _, h := range hopHeaders {
+		if strings.EqualFold(key, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// MapModel maps a requested Claude model onto the provider's model for the
+// same family (opus, sonnet, haiku), falling back to the main model. The
+// name is returned unchanged if the provider configures no matching model
+func MapModel(p *config.Provider, model string) string {
+	lower := strings.ToLower(model)
+
+	var candidates []string
+	switch {
+	case strings.Contains(lower, "opus"):
+		candidates = []string{p.OpusModel, p.Model}
+	case strings.Contains(lower, "sonnet"):
+		candidates = []string{p.SonnetModel, p.Model}
+	case strings.Contains(lower, "haiku"):
+		candidates = []string{p.HaikuModel, p.SmallModel, p.Model}
+	default:
+		candidates = []string{p.Model}
+	}
+
+	for _, c := range candidates {
+		if c != "" {
+			return c
+		}
+	}
+	return model
+}
+
+// rewriteModel replaces the "model" field of a JSON request body with the
+// provider's mapped model, returning the body unchanged if it has none
+func rewriteModel(body []byte, p *config.Provider) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	var model string
+	if raw, ok := fields["model"]; !ok || json.Unmarshal(raw, &model) != nil {
+		return body
+	}
+
+	mapped := MapModel(p, model)
+	if mapped == model {
+		return body
+	}
+
+	fields["model"], _ = json.Marshal(mapped)
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// requestModel returns the "model" field of a JSON request body
+func requestModel(body []byte) string {
+	var req struct {
+		Model string `json:"model"`
+	}
+	json.Unmarshal(body, &req)
+	return req.Model
+}
+
+// writeStatusError writes an Anthropic-style error response whose type
+// follows the status
+func writeStatusError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(errorBody(status, message))
+}
+
+// writeError writes an Anthropic-style error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type": "error",
+		"error": map[string]string{
+			"type":    "api_error",
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+func TestServeHTTPRequiresProxyToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var upstreamKey string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamKey = r.Header.Get("x-api-key")
+		io.WriteString(w, `{"type":"message","content":[]}`)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		CurrentProvider: "a",
+		Providers:       []config.Provider{{Name: "A", Alias: "a", BaseURL: upstream.URL, APIKey: "sk-real"}},
+	}
+	token, err := cfg.EnsureProxyToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(&Server{})
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"no token", "", "", http.StatusUnauthorized},
+		{"wrong x-api-key", "x-api-key", "sk-real", http.StatusUnauthorized},
+		{"wrong bearer", "Authorization", "Bearer other", http.StatusUnauthorized},
+		{"token without Bearer", "Authorization", token, http.StatusUnauthorized},
+		{"x-api-key", "x-api-key", token, http.StatusOK},
+		{"bearer", "Authorization", "Bearer " + token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		for _, path := range []string{messagesPath, StatusPath} {
+			upstreamKey = ""
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(`{"model":"m"}`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s: status = %d, want %d (%s)", tt.name, path, resp.StatusCode, tt.want, body)
+			}
+			if tt.want == http.StatusUnauthorized && !strings.Contains(string(body), "authentication_error") {
+				t.Errorf("%s %s: body = %s", tt.name, path, body)
+			}
+			if path == messagesPath && tt.want == http.StatusOK && upstreamKey != "sk-real" {
+				t.Errorf("%s: upstream key = %q, want the provider's key", tt.name, upstreamKey)
+			}
+			if tt.want == http.StatusUnauthorized && upstreamKey != "" {
+				t.Errorf("%s %s: forwarded without the proxy token", tt.name, path)
+			}
+		}
+	}
+}
+
+func TestServeHTTPRejectsRemoteHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &config.Config{}
+	token, err := cfg.EnsureProxyToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host        string
+		allowRemote bool
+		want        int
+	}{
+		{"127.0.0.1:8787", false, http.StatusOK},
+		{"localhost:8787", false, http.StatusOK},
+		{"[::1]:8787", false, http.StatusOK},
+		{"localhost", false, http.StatusOK},
+		{"attacker.example.com:8787", false, http.StatusForbidden},
+		{"192.168.1.10:8787", false, http.StatusForbidden},
+		{"192.168.1.10:8787", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, StatusPath, nil)
+		req.Host = tt.host
+		req.Header.Set("x-api-key", token)
+		w := httptest.NewRecorder()
+		(&Server{AllowRemote: tt.allowRemote}).ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("Host %s (allow remote %v): status = %d, want %d", tt.host, tt.allowRemote, w.Code, tt.want)
+		}
+	}
+}
+
+func TestServeProviderFollowsRedirects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"type":"message","content":[]}`)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Providers: []config.Provider{{Name: "New", Alias: "new", BaseURL: upstream.URL, APIKey: "sk"}},
+		Chains:    []config.Chain{{Alias: "c", Providers: []string{"old"}}},
+		Redirects: map[string]string{"old": "new"},
+	}
+	token, err := cfg.EnsureProxyToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(&Server{})
+	defer srv.Close()
+
+	for _, path := range []string{ProviderPrefix + "old" + messagesPath, chainPrefix + "c" + messagesPath} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(`{"model":"m"}`))
+		req.Header.Set("x-api-key", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200 (%s)", path, resp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// Replay sends a request to a provider the way the proxy forwards it,
+// including key injection and API translation. With rewrite set the model
+// is mapped onto the provider's models. The caller closes the response body
+func (s *Server) Replay(ctx context.Context, method, path string, header http.Header, body []byte, p *config.Provider, rewrite bool) (*http.Response, error) {
+	r, err := http.NewRequestWithContext(ctx, method, "http://ccs"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Header = header.Clone()
+
+	if rewrite {
+		body = rewriteModel(body, p)
+	}
+	return s.send(r, path, body, p)
+}
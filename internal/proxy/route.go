@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// routePrefix is the path prefix of model router endpoints
+const routePrefix = "/route/"
+
+// serveRoute forwards a request under /route/<alias>/ to the target picked
+// by the router's first matching rule
+func (s *Server) serveRoute(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	rest := strings.TrimPrefix(r.URL.Path, routePrefix)
+	alias, apiPath, _ := strings.Cut(rest, "/")
+	apiPath = "/" + apiPath
+
+	router, err := cfg.GetRouter(alias)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("router '%s' not found", alias))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request: %v", err))
+		return
+	}
+
+	target, rewrite := router.Default, true
+	if rule := matchRule(router.Rules, r.Header, body); rule != nil {
+		target, rewrite = rule.Target, rule.RewriteModel
+	}
+
+	providers, err := s.resolveTarget(cfg, target)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("router '%s': %v", alias, err))
+		return
+	}
+
+	s.logf("route %s: %s -> %s", alias, requestModel(body), target)
+	s.forward(w, r, apiPath, body, providers, rewrite)
+}
+
+// resolveTarget returns the providers behind a provider or chain alias
+func (s *Server) resolveTarget(cfg *config.Config, alias string) ([]*config.Provider, error) {
+	if p, err := s.provider(cfg, alias); err == nil {
+		return []*config.Provider{p}, nil
+	}
+
+	chain, err := cfg.GetChain(alias)
+	if err != nil {
+		return nil, fmt.Errorf("target '%s' is neither a provider nor a chain", alias)
+	}
+
+	var providers []*config.Provider
+	for _, a := range chain.Providers {
+		if p, err := s.provider(cfg, a); err == nil {
+			providers = append(providers, p)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("chain '%s' has no usable providers", alias)
+	}
+	return providers, nil
+}
+
+// matchRule returns the first rule whose conditions all match the request
+func matchRule(rules []config.RouteRule, header http.Header, body []byte) *config.RouteRule {
+	model := strings.ToLower(requestModel(body))
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Model != "" && !config.GlobMatch(strings.ToLower(rule.Model), model) {
+			continue
+		}
+		if rule.Header != "" && !headerMatch(rule.Header, header) {
+			continue
+		}
+		if rule.MinBytes > 0 && len(body) < rule.MinBytes {
+			continue
+		}
+		if rule.MaxBytes > 0 && len(body) > rule.MaxBytes {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// headerMatch checks a "Name" or "Name=glob" header condition
+func headerMatch(cond string, header http.Header) bool {
+	name, pattern, hasValue := strings.Cut(cond, "=")
+	values := header.Values(strings.TrimSpace(name))
+	if !hasValue {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		if config.GlobMatch(strings.TrimSpace(pattern), v) {
+			return true
+		}
+	}
+	return false
+}
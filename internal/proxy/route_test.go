@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+func TestMatchRule(t *testing.T) {
+	rules := []config.RouteRule{
+		{Model: "*haiku*", Target: "cheap"},
+		{Header: "X-Ccs-Route=fast*", Target: "fast"},
+		{Header: "X-Priority", Target: "priority"},
+		{MinBytes: 100, Target: "long"},
+		{Model: "*opus*", MaxBytes: 60, Target: "opus"},
+		{Model: "claude-sonnet-?", Target: "sonnet"},
+	}
+
+	tests := []struct {
+		name   string
+		model  string
+		header http.Header
+		want   string // "" for no match
+	}{
+		{"model glob", "claude-3-5-haiku-20241022", nil, "cheap"},
+		{"model glob is case-insensitive", "Claude-HAIKU", nil, "cheap"},
+		{"star spans slashes", "anthropic/claude-opus-4.1", nil, "opus"},
+		{"question mark is one character", "claude-sonnet-4", nil, "sonnet"},
+		{"question mark needs a character", "claude-sonnet-", nil, ""},
+		{"no rule matches", "gpt-4o", nil, ""},
+		{"header glob", "gpt-4o", http.Header{"X-Ccs-Route": {"fast-lane"}}, "fast"},
+		{"header glob mismatch", "gpt-4o", http.Header{"X-Ccs-Route": {"slow"}}, ""},
+		{"header present", "gpt-4o", http.Header{"X-Priority": {""}}, "priority"},
+		{"first matching rule wins", "claude-haiku", http.Header{"X-Priority": {"1"}}, "cheap"},
+		{"conditions are all required", "anthropic/claude-opus-4.1-with-a-long-suffix-xxxxxxxxxxxx", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"model":"` + tt.model + `"}`)
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			rule := matchRule(rules, header, body)
+			got := ""
+			if rule != nil {
+				got = rule.Target
+			}
+			if got != tt.want {
+				t.Errorf("matchRule(%q, %v) = %q, want %q", tt.model, tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRuleBodySize(t *testing.T) {
+	rules := []config.RouteRule{{MinBytes: 100, Target: "long"}}
+	short := []byte(`{"model":"m"}`)
+	long := []byte(`{"model":"m","messages":[{"role":"user","content":"` + string(make([]byte, 100)) + `"}]}`)
+
+	if rule := matchRule(rules, http.Header{}, short); rule != nil {
+		t.Errorf("short body matched %q", rule.Target)
+	}
+	if rule := matchRule(rules, http.Header{}, long); rule == nil || rule.Target != "long" {
+		t.Errorf("long body matched %v, want long", rule)
+	}
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// configStore caches config.json and reloads it when the file changes, so
+// ccs use takes effect on the next request without restarting the proxy
+type configStore struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	modTime time.Time
+	size    int64
+}
+
+// get returns the current config, reloading it if the file changed
+func (cs *configStore) get() (*config.Config, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	path, err := config.GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var modTime time.Time
+	var size int64
+	if info != nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	if cs.cfg != nil && modTime.Equal(cs.modTime) && size == cs.size {
+		return cs.cfg, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cs.cfg, cs.modTime, cs.size = cfg, modTime, size
+	return cfg, nil
+}
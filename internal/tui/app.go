@@ -0,0 +1,700 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katz/ccs/internal/claude"
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/display"
+	"github.com/katz/ccs/internal/probe"
+)
+
+// probeTimeout bounds the connectivity test
+const probeTimeout = 10 * time.Second
+
+// mode is what the screen currently shows
+type mode int
+
+const (
+	modeList mode = iota
+	modeForm
+	modeDelete // Confirming a delete
+	modeClone  // Asking for the clone's alias
+	modeEnv
+)
+
+// listHelp is the key help of the provider list
+const listHelp = "enter switch  e edit  a add  c clone  d delete  f favorite  t test  v env  q quit"
+
+// App is the full-screen provider manager. Config is modified in place and
+// saved after every change; the hooks let callers supply the settings.json
+// logic and stub out side effects when driving the UI from a script
+type App struct {
+	Config *config.Config
+	In     Input
+	Out    io.Writer
+
+	// Size returns the screen size, 80x24 if nil
+	Size func() (width, height int)
+	// Save persists the config, Config.Save if nil
+	Save func(cfg *config.Config) error
+	// Apply writes p to settings.json in place of the applied provider,
+	// which may be nil, returning warnings to show. A nil p only removes
+	// the applied provider's settings. Switching is unavailable if nil
+	Apply func(cfg *config.Config, applied, p *config.Provider) ([]string, error)
+	// Rename moves the history and usage records of a provider whose alias
+	// was edited, nothing is moved if nil
+	Rename func(oldAlias, newAlias string) error
+	// Probe tests a provider's connectivity, a GET /v1/models if nil
+	Probe func(p *config.Provider) probe.Result
+	// Env returns the env ccs manages in settings.json, read with
+	// claude.Settings if nil
+	Env func() (map[string]string, error)
+
+	ShowSecrets bool // Show API keys and tokens unmasked
+
+	mode   mode
+	cursor int // Selected row, in display order
+	offset int // First row shown
+	form   *form
+	input  []rune   // Clone alias
+	env    []string // Env view lines
+	scroll int      // First env line shown
+	status Line
+	quit   bool
+}
+
+// Run shows the UI until the user quits or the input ends
+func (a *App) Run() error {
+	io.WriteString(a.Out, enterScreen)
+	defer io.WriteString(a.Out, leaveScreen)
+
+	a.selectAlias(a.Config.CurrentProvider)
+	for !a.quit {
+		if err := a.draw(); err != nil {
+			return err
+		}
+		k, err := a.In.ReadKey()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a.Update(k)
+	}
+	return nil
+}
+
+// Quit reports whether the user asked to quit
+func (a *App) Quit() bool {
+	return a.quit
+}
+
+// Status returns the status line, the outcome of the last action
+func (a *App) Status() string {
+	return a.status.Text
+}
+
+// draw renders the current frame
+func (a *App) draw() error {
+	width, _ := a.size()
+	return render(a.Out, a.View(), width)
+}
+
+// size returns the screen size
+func (a *App) size() (int, int) {
+	if a.Size == nil {
+		return 80, 24
+	}
+	width, height := a.Size()
+	if width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Update handles one key press
+func (a *App) Update(k Key) {
+	if k.Code == KeyCtrlC {
+		a.quit = true
+		return
+	}
+
+	switch a.mode {
+	case modeList:
+		a.updateList(k)
+	case modeForm:
+		a.updateForm(k)
+	case modeDelete:
+		a.updateDelete(k)
+	case modeClone:
+		a.updateClone(k)
+	case modeEnv:
+		a.updateEnv(k)
+	}
+}
+
+func (a *App) updateList(k Key) {
+	a.status = Line{}
+	switch {
+	case k.Code == KeyUp || k.Rune == 'k':
+		a.moveCursor(-1)
+	case k.Code == KeyDown || k.Rune == 'j':
+		a.moveCursor(1)
+	case k.Code == KeyPgUp:
+		a.moveCursor(-a.listHeight())
+	case k.Code == KeyPgDown:
+		a.moveCursor(a.listHeight())
+	case k.Code == KeyHome || k.Rune == 'g':
+		a.moveCursor(-len(a.Config.Providers))
+	case k.Code == KeyEnd || k.Rune == 'G':
+		a.moveCursor(len(a.Config.Providers))
+	case k.Code == KeyEsc || k.Rune == 'q':
+		a.quit = true
+	case k.Rune == 'a':
+		a.form = newForm("Add provider", config.Provider{Timeout: 300000}, "")
+		a.mode = modeForm
+	case k.Rune == 'v':
+		a.showEnv()
+	}
+
+	p := a.selected()
+	if p == nil {
+		return
+	}
+	switch {
+	case k.Code == KeyEnter:
+		a.switchTo(p)
+	case k.Rune == 'e':
+		a.form = newForm(fmt.Sprintf("Edit '%s'", p.Alias), *p, p.Alias)
+		a.mode = modeForm
+	case k.Rune == 'c':
+		a.input = nil
+		a.mode = modeClone
+	case k.Rune == 'd':
+		a.mode = modeDelete
+	case k.Rune == 'f':
+		a.toggleFavorite(p)
+	case k.Rune == 't':
+		a.test(p)
+	}
+}
+
+// toggleFavorite marks or unmarks a favorite, keeping the cursor on it as
+// it moves
+func (a *App) toggleFavorite(p *config.Provider) {
+	p.Favorite = !p.Favorite
+	if err := a.save(); err != nil {
+		a.setStatus(StyleError, "Failed to save: %v", err)
+		return
+	}
+	a.selectAlias(p.Alias)
+	if p.Favorite {
+		a.setStatus(StyleOK, "'%s' is a favorite", p.Alias)
+	} else {
+		a.setStatus(StyleOK, "'%s' is no longer a favorite", p.Alias)
+	}
+}
+
+// selected returns the provider under the cursor, nil if there are none
+func (a *App) selected() *config.Provider {
+	if a.cursor < 0 || a.cursor >= len(a.Config.Providers) {
+		return nil
+	}
+	return &a.Config.Providers[a.Config.OrderedIndexes()[a.cursor]]
+}
+
+// moveCursor moves the cursor, clamped to the list
+func (a *App) moveCursor(delta int) {
+	a.cursor += delta
+	if a.cursor >= len(a.Config.Providers) {
+		a.cursor = len(a.Config.Providers) - 1
+	}
+	if a.cursor < 0 {
+		a.cursor = 0
+	}
+}
+
+// selectAlias moves the cursor to a provider
+func (a *App) selectAlias(alias string) {
+	for row, i := range a.Config.OrderedIndexes() {
+		if a.Config.Providers[i].Alias == alias {
+			a.cursor = row
+			return
+		}
+	}
+}
+
+// save persists the config
+func (a *App) save() error {
+	if a.Save != nil {
+		return a.Save(a.Config)
+	}
+	return a.Config.Save()
+}
+
+func (a *App) setStatus(style Style, format string, args ...interface{}) {
+	a.status = Line{Text: fmt.Sprintf(format, args...), Style: style}
+}
+
+// switchTo makes p the current provider, as 'ccs use' does
+func (a *App) switchTo(p *config.Provider) {
+	if a.Apply == nil {
+		a.setStatus(StyleError, "Switching is not available")
+		return
+	}
+
+	current, _ := a.Config.GetCurrentProvider()
+	warnings, err := a.Apply(a.Config, current, p)
+	if err != nil {
+		a.setStatus(StyleError, "Failed to update Claude settings: %v", err)
+		return
+	}
+
+	a.Config.CurrentProvider = p.Alias
+	now := time.Now()
+	p.LastUsed = &now
+	if err := a.save(); err != nil {
+		a.setStatus(StyleError, "Failed to save config: %v", err)
+		return
+	}
+	if err := config.RecordSwitch(p.Alias, config.ScopeUser); err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to record history: %v", err))
+	}
+
+	// Ordered by recent use, the provider moves to the top
+	a.selectAlias(p.Alias)
+
+	switch {
+	case len(warnings) > 0:
+		a.setStatus(StyleError, "Switched to '%s'. Warning: %s", p.Name, strings.Join(warnings, "; "))
+	case p.IsOpenAI():
+		a.setStatus(StyleOK, "Switched to '%s', keep 'ccs proxy' running to translate requests", p.Name)
+	default:
+		a.setStatus(StyleOK, "Switched to '%s'", p.Name)
+	}
+}
+
+// test checks a provider's connectivity. The probe blocks, so a frame
+// saying so is drawn first
+func (a *App) test(p *config.Provider) {
+	a.setStatus(StyleDim, "Testing '%s'...", p.Alias)
+	a.draw()
+
+	var r probe.Result
+	if a.Probe != nil {
+		r = a.Probe(p)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		r = (&probe.Prober{Timeout: probeTimeout}).Ping(ctx, p)
+	}
+
+	if r.OK {
+		a.setStatus(StyleOK, "'%s' OK in %dms", p.Alias, r.LatencyMS)
+	} else {
+		a.setStatus(StyleError, "'%s' failed: %s", p.Alias, r.Error)
+	}
+}
+
+// showEnv switches to the settings.json env view
+func (a *App) showEnv() {
+	var env map[string]string
+	var err error
+	if a.Env != nil {
+		env, err = a.Env()
+	} else {
+		var settings *claude.Settings
+		settings, err = claude.LoadSettings()
+		if err == nil {
+			env = settings.GetCurrentEnvConfig()
+		}
+	}
+	if err != nil {
+		a.setStatus(StyleError, "Failed to read Claude settings: %v", err)
+		return
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	a.env = nil
+	for _, key := range keys {
+		value := env[key]
+		if !a.ShowSecrets && config.IsSecretKey(key) {
+			value = config.MaskSecret(value)
+		}
+		a.env = append(a.env, fmt.Sprintf("%s=%s", key, value))
+	}
+	if len(a.env) == 0 {
+		a.env = []string{"(no provider env in settings.json)"}
+	}
+	a.scroll = 0
+	a.mode = modeEnv
+}
+
+func (a *App) updateEnv(k Key) {
+	switch {
+	case k.Code == KeyUp || k.Rune == 'k':
+		if a.scroll > 0 {
+			a.scroll--
+		}
+	case k.Code == KeyDown || k.Rune == 'j':
+		if a.scroll < len(a.env)-1 {
+			a.scroll++
+		}
+	case k.Code == KeyEsc || k.Code == KeyEnter || k.Rune == 'q' || k.Rune == 'v':
+		a.mode = modeList
+	}
+}
+
+func (a *App) updateDelete(k Key) {
+	a.mode = modeList
+	p := a.selected()
+	if p == nil || (k.Rune != 'y' && k.Rune != 'Y') {
+		a.setStatus(StyleDim, "Delete cancelled")
+		return
+	}
+
+	removed := p.Clone()
+	isCurrent := removed.Alias == a.Config.CurrentProvider
+	if err := a.Config.TrashProvider(removed.Alias); err != nil {
+		a.setStatus(StyleError, "Failed to delete: %v", err)
+		return
+	}
+	// The config is saved first, so a failed save leaves settings.json
+	// matching the providers still on disk
+	if err := a.save(); err != nil {
+		a.setStatus(StyleError, "Failed to save: %v", err)
+		return
+	}
+	a.moveCursor(0)
+
+	// The current provider's token must not stay live in settings.json
+	var warnings []string
+	if isCurrent && a.Apply != nil {
+		var err error
+		if warnings, err = a.Apply(a.Config, &removed, nil); err != nil {
+			a.setStatus(StyleError, "Provider '%s' moved to the trash, but failed to update Claude settings: %v", removed.Name, err)
+			return
+		}
+	}
+
+	switch {
+	case len(warnings) > 0:
+		a.setStatus(StyleError, "Provider '%s' moved to the trash. Warning: %s", removed.Name, strings.Join(warnings, "; "))
+	case isCurrent:
+		a.setStatus(StyleOK, "Provider '%s' moved to the trash and removed from settings.json", removed.Name)
+	default:
+		a.setStatus(StyleOK, "Provider '%s' moved to the trash", removed.Name)
+	}
+}
+
+func (a *App) updateClone(k Key) {
+	a.status = Line{}
+	switch k.Code {
+	case KeyEsc:
+		a.mode = modeList
+	case KeyBackspace:
+		if len(a.input) > 0 {
+			a.input = a.input[:len(a.input)-1]
+		}
+	case KeyCtrlU:
+		a.input = nil
+	case KeyRune:
+		a.input = append(a.input, k.Rune)
+	case KeyEnter:
+		alias := strings.TrimSpace(string(a.input))
+		if alias == "" {
+			return
+		}
+		if err := config.ValidateAlias(alias); err != nil {
+			a.setStatus(StyleError, "Invalid alias %q, use letters, digits, '.', '_' and '-'", alias)
+			return
+		}
+		if _, err := a.Config.GetProvider(alias); err == nil {
+			a.setStatus(StyleError, "Provider '%s' already exists", alias)
+			return
+		}
+
+		src := a.selected()
+		clone := src.Clone()
+		clone.Alias = alias
+		clone.LastUsed = nil
+		a.form = newForm(fmt.Sprintf("Clone of '%s'", src.Alias), clone, "")
+		a.mode = modeForm
+	}
+}
+
+func (a *App) updateForm(k Key) {
+	f := a.form
+	if f.editing {
+		a.status = Line{}
+		if err := f.handleInput(k); err != nil {
+			a.setStatus(StyleError, "%v", err)
+		}
+		return
+	}
+
+	a.status = Line{}
+	switch {
+	case k.Code == KeyUp || k.Rune == 'k':
+		f.move(-1)
+	case k.Code == KeyDown || k.Code == KeyTab || k.Rune == 'j':
+		f.move(1)
+	case k.Code == KeyEnter:
+		f.startEdit()
+	case k.Code == KeyEsc || k.Rune == 'q':
+		a.mode = modeList
+		a.setStatus(StyleDim, "Changes discarded")
+	case k.Rune == 's':
+		a.saveForm()
+	}
+}
+
+// saveForm adds or updates the provider being edited. A change to the
+// current provider is applied to settings.json, as 'ccs edit' does
+func (a *App) saveForm() {
+	f := a.form
+	if err := f.validate(); err != nil {
+		a.setStatus(StyleError, "%v", err)
+		return
+	}
+	p := f.provider
+
+	if f.original == "" {
+		if err := a.Config.AddProvider(p); err != nil {
+			if errors.Is(err, config.ErrProviderExists) {
+				err = fmt.Errorf("provider '%s' already exists", p.Alias)
+			}
+			a.setStatus(StyleError, "%v", err)
+			return
+		}
+		if err := a.save(); err != nil {
+			a.setStatus(StyleError, "Failed to save: %v", err)
+			return
+		}
+		a.mode = modeList
+		a.selectAlias(p.Alias)
+		a.setStatus(StyleOK, "Provider '%s' added", p.Name)
+		return
+	}
+
+	old, err := a.Config.GetProvider(f.original)
+	if err != nil {
+		a.setStatus(StyleError, "Provider '%s' not found", f.original)
+		return
+	}
+	original := old.Clone()
+	isCurrent := a.Config.CurrentProvider == f.original
+
+	if err := a.Config.UpdateProvider(f.original, p); err != nil {
+		if errors.Is(err, config.ErrProviderExists) {
+			err = fmt.Errorf("provider '%s' already exists", p.Alias)
+		}
+		a.setStatus(StyleError, "%v", err)
+		return
+	}
+	if err := a.save(); err != nil {
+		a.setStatus(StyleError, "Failed to save: %v", err)
+		return
+	}
+
+	a.mode = modeList
+	a.selectAlias(p.Alias)
+	a.setStatus(StyleOK, "Provider '%s' updated", p.Name)
+	if p.Alias != f.original && a.Rename != nil {
+		if err := a.Rename(f.original, p.Alias); err != nil {
+			a.setStatus(StyleError, "Provider '%s' updated, but its history was not: %v", p.Name, err)
+		}
+	}
+	if isCurrent && a.Apply != nil {
+		updated, _ := a.Config.GetProvider(p.Alias)
+		warnings, err := a.Apply(a.Config, &original, updated)
+		if err != nil {
+			a.setStatus(StyleError, "Provider '%s' updated, but Claude settings were not: %v", p.Name, err)
+		} else if len(warnings) > 0 {
+			a.setStatus(StyleError, "Provider '%s' updated. Warning: %s", p.Name, strings.Join(warnings, "; "))
+		}
+	}
+}
+
+// listHeight is the number of provider rows that fit on screen
+func (a *App) listHeight() int {
+	_, height := a.size()
+	// Title, header, detail, status and help lines
+	if n := height - 5; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// View returns the current frame
+func (a *App) View() []Line {
+	width, height := a.size()
+
+	var lines []Line
+	var help string
+	switch a.mode {
+	case modeForm:
+		lines = a.form.lines(a.ShowSecrets)
+		help = a.form.help()
+	case modeEnv:
+		lines = []Line{{Text: " settings.json env managed by ccs", Style: StyleTitle}, {}}
+		for _, line := range a.env[a.scroll:] {
+			lines = append(lines, Line{Text: "  " + line})
+		}
+		help = "up/down scroll  esc back"
+	default:
+		lines = a.listLines(width)
+		help = listHelp
+	}
+
+	status := a.status
+	switch a.mode {
+	case modeDelete:
+		status = Line{Text: fmt.Sprintf("Move '%s' to the trash? (y/N)", a.selected().Name), Style: StyleError}
+		help = "y delete  any other key cancel"
+	case modeClone:
+		prompt := fmt.Sprintf("Alias for the clone of '%s': %s_", a.selected().Alias, string(a.input))
+		status = Line{Text: prompt}
+		// A rejected alias keeps the prompt open, with the reason after it
+		if a.status.Text != "" {
+			status = Line{Text: prompt + "  " + a.status.Text, Style: StyleError}
+		}
+		help = "enter continue  esc cancel"
+	}
+
+	// Body, then status and help pinned to the bottom
+	body := height - 2
+	if len(lines) > body {
+		lines = lines[:body]
+	}
+	for len(lines) < body {
+		lines = append(lines, Line{})
+	}
+	return append(lines, status, Line{Text: " " + help, Style: StyleDim})
+}
+
+// listLines renders the title, provider table and details of the selected
+// provider
+func (a *App) listLines(width int) []Line {
+	cfg := a.Config
+	title := fmt.Sprintf(" ccs - %d providers", len(cfg.Providers))
+	if cfg.CurrentProvider != "" {
+		title += fmt.Sprintf(", current: %s", cfg.CurrentProvider)
+	}
+	lines := []Line{{Text: title, Style: StyleTitle}}
+
+	if len(cfg.Providers) == 0 {
+		return append(lines, Line{}, Line{Text: "  No providers configured, press 'a' to add one"})
+	}
+
+	header := []string{"ALIAS", "NAME", "HOST", "MODEL", "TAGS"}
+	order := cfg.OrderedIndexes()
+	rows := make([][]string, len(order))
+	for i, j := range order {
+		p := &cfg.Providers[j]
+		model := p.Model
+		if model == "" {
+			model = "default"
+		}
+		rows[i] = []string{p.Alias, p.Name, host(p.BaseURL), model, strings.Join(p.Tags, ",")}
+	}
+	widths := columnWidths(header, rows, []int{16, 24, 32, 32, 0})
+	lines = append(lines, Line{Text: "    " + formatRow(header, widths), Style: StyleDim})
+
+	// Keep the cursor on screen
+	n := a.listHeight()
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+n {
+		a.offset = a.cursor - n + 1
+	}
+
+	for i := a.offset; i < len(rows) && i < a.offset+n; i++ {
+		p := &cfg.Providers[order[i]]
+		marker := []rune("    ")
+		style := StyleNormal
+		if p.Alias == cfg.CurrentProvider {
+			marker[1] = '*'
+			style = StyleCurrent
+		}
+		if p.Favorite {
+			marker[2] = '★'
+		}
+		if i == a.cursor {
+			marker[0] = '>'
+			style = StyleSelected
+		}
+		lines = append(lines, Line{Text: string(marker) + formatRow(rows[i], widths), Style: style})
+	}
+	for len(lines) < n+2 {
+		lines = append(lines, Line{})
+	}
+
+	if p := a.selected(); p != nil {
+		key := p.APIKey
+		if !a.ShowSecrets {
+			key = config.MaskSecret(key)
+		}
+		detail := fmt.Sprintf("    %s  key %s  timeout %dms", p.BaseURL, key, p.Timeout)
+		if p.IsOpenAI() {
+			detail += "  openai"
+		}
+		lines = append(lines, Line{Text: fit(detail, width), Style: StyleDim})
+	}
+	return lines
+}
+
+// columnWidths sizes columns to their widest cell in terminal columns,
+// capped by limits where a limit is positive
+func columnWidths(header []string, rows [][]string, limits []int) []int {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = display.Width(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := display.Width(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for i, limit := range limits {
+		if limit > 0 && widths[i] > limit {
+			widths[i] = limit
+		}
+	}
+	return widths
+}
+
+// formatRow pads and truncates cells to the column widths
+func formatRow(cells []string, widths []int) string {
+	parts := make([]string, len(cells))
+	for i, cell := range cells {
+		parts[i] = pad(fit(cell, widths[i]), widths[i])
+	}
+	return strings.TrimRight(strings.Join(parts, "  "), " ")
+}
+
+// host returns the host of a base URL
+func host(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return baseURL
+	}
+	return u.Host
+}
@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/katz/ccs/internal/config"
+	"github.com/katz/ccs/internal/probe"
+)
+
+// stubs records the side effects an App asked for
+type stubs struct {
+	saves   int
+	applied []string // "from->to", with "" for nil
+}
+
+// runScript drives an App over a two-provider config with a key script,
+// returning it with the recorded side effects. setup may replace the stubs
+func runScript(t *testing.T, script string, setup ...func(a *App, s *stubs)) (*App, *stubs) {
+	t.Helper()
+	// Switches are recorded in the history under the home directory
+	t.Setenv("HOME", t.TempDir())
+
+	in, err := NewScript(script)
+	if err != nil {
+		t.Fatalf("NewScript(%q): %v", script, err)
+	}
+
+	cfg := &config.Config{
+		CurrentProvider: "a",
+		Providers: []config.Provider{
+			{Name: "Provider A", Alias: "a", BaseURL: "https://a.example.com", APIKey: "sk-aaaaaaaaaaaa", Model: "model-a", Timeout: 1000},
+			{Name: "Provider B", Alias: "b", BaseURL: "https://b.example.com", APIKey: "sk-bbbbbbbbbbbb", Timeout: 2000},
+		},
+	}
+
+	s := &stubs{}
+	alias := func(p *config.Provider) string {
+		if p == nil {
+			return ""
+		}
+		return p.Alias
+	}
+	app := &App{
+		Config: cfg,
+		In:     in,
+		Out:    io.Discard,
+		Size:   func() (int, int) { return 100, 20 },
+		Save: func(cfg *config.Config) error {
+			s.saves++
+			return nil
+		},
+		Apply: func(cfg *config.Config, applied, p *config.Provider) ([]string, error) {
+			s.applied = append(s.applied, alias(applied)+"->"+alias(p))
+			return nil, nil
+		},
+		Probe: func(p *config.Provider) probe.Result {
+			return probe.Result{OK: true, LatencyMS: 42}
+		},
+		Env: func() (map[string]string, error) {
+			return map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://a.example.com",
+				"ANTHROPIC_AUTH_TOKEN": "sk-aaaaaaaaaaaa",
+			}, nil
+		},
+	}
+	for _, f := range setup {
+		f(app, s)
+	}
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return app, s
+}
+
+// viewText joins the rendered frame
+func viewText(a *App) string {
+	var b strings.Builder
+	for _, line := range a.View() {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// assertView checks that the frame contains every string
+func assertView(t *testing.T, a *App, want ...string) {
+	t.Helper()
+	view := viewText(a)
+	for _, w := range want {
+		if !strings.Contains(view, w) {
+			t.Errorf("view does not contain %q:\n%s", w, view)
+		}
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	app, s := runScript(t, "down enter")
+
+	if app.Config.CurrentProvider != "b" {
+		t.Errorf("current = %q, want b", app.Config.CurrentProvider)
+	}
+	if len(s.applied) != 1 || s.applied[0] != "a->b" {
+		t.Errorf("applied = %v, want [a->b]", s.applied)
+	}
+	if s.saves != 1 {
+		t.Errorf("saves = %d, want 1", s.saves)
+	}
+	if p, _ := app.Config.GetProvider("b"); p.LastUsed == nil {
+		t.Error("LastUsed not set")
+	}
+	assertView(t, app, "current: b", "Switched to 'Provider B'")
+
+	history, err := config.LoadHistory()
+	if err != nil || len(history) != 1 || history[0].Alias != "b" {
+		t.Errorf("history = %v, %v, want one switch to b", history, err)
+	}
+}
+
+func TestInlineEdit(t *testing.T) {
+	app, s := runScript(t, "e down down enter ctrl-u https://new.example.com enter s")
+
+	p, err := app.Config.GetProvider("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BaseURL != "https://new.example.com" {
+		t.Errorf("BaseURL = %q", p.BaseURL)
+	}
+	// The current provider is reapplied to settings.json
+	if len(s.applied) != 1 || s.applied[0] != "a->a" {
+		t.Errorf("applied = %v, want [a->a]", s.applied)
+	}
+	assertView(t, app, "new.example.com", "Provider 'Provider A' updated")
+}
+
+func TestEditAliasRenamesRecords(t *testing.T) {
+	var renamed []string
+	app, _ := runScript(t, "e down enter ctrl-u a2 enter s", func(a *App, s *stubs) {
+		a.Rename = func(oldAlias, newAlias string) error {
+			renamed = append(renamed, oldAlias+"->"+newAlias)
+			return nil
+		}
+	})
+
+	if app.Config.CurrentProvider != "a2" {
+		t.Errorf("current = %q, want a2", app.Config.CurrentProvider)
+	}
+	if len(renamed) != 1 || renamed[0] != "a->a2" {
+		t.Errorf("renamed = %v, want [a->a2]", renamed)
+	}
+}
+
+func TestEditRejectsInvalidValue(t *testing.T) {
+	app, s := runScript(t, "e down down down down down down down down down down enter ctrl-u soon enter")
+
+	if s.saves != 0 {
+		t.Errorf("saves = %d, want 0", s.saves)
+	}
+	assertView(t, app, "Timeout ms", "timeout must be a number of milliseconds")
+}
+
+func TestAdd(t *testing.T) {
+	app, s := runScript(t, "a enter New enter down enter new enter down enter https://n.example.com enter down enter sk-nnnnnnnn enter s")
+
+	p, err := app.Config.GetProvider("new")
+	if err != nil {
+		t.Fatalf("provider not added: %v", err)
+	}
+	if p.Name != "New" || p.BaseURL != "https://n.example.com" || p.APIKey != "sk-nnnnnnnn" {
+		t.Errorf("added %+v", p)
+	}
+	if s.saves != 1 || len(s.applied) != 0 {
+		t.Errorf("saves = %d, applied = %v", s.saves, s.applied)
+	}
+	assertView(t, app, "3 providers", "n.example.com", "Provider 'New' added")
+}
+
+func TestAddRequiresFields(t *testing.T) {
+	app, s := runScript(t, "a enter New enter s")
+
+	if s.saves != 0 || len(app.Config.Providers) != 2 {
+		t.Errorf("saves = %d, providers = %d", s.saves, len(app.Config.Providers))
+	}
+	assertView(t, app, "Add provider", "alias is required")
+}
+
+func TestClone(t *testing.T) {
+	app, _ := runScript(t, "c a2 enter s")
+
+	p, err := app.Config.GetProvider("a2")
+	if err != nil {
+		t.Fatalf("clone not added: %v", err)
+	}
+	if p.BaseURL != "https://a.example.com" || p.Model != "model-a" || p.LastUsed != nil {
+		t.Errorf("clone %+v", p)
+	}
+	assertView(t, app, "3 providers", "a2")
+}
+
+func TestCloneExistingAlias(t *testing.T) {
+	app, _ := runScript(t, "c b enter")
+
+	assertView(t, app, "Provider 'b' already exists")
+}
+
+func TestDeleteToTrash(t *testing.T) {
+	app, s := runScript(t, "down d y")
+
+	if _, err := app.Config.GetProvider("b"); err == nil {
+		t.Error("b still configured")
+	}
+	if len(app.Config.Trash) != 1 || app.Config.Trash[0].Provider.Alias != "b" {
+		t.Errorf("trash = %+v, want b", app.Config.Trash)
+	}
+	// Not the current provider, settings.json is left alone
+	if len(s.applied) != 0 {
+		t.Errorf("applied = %v", s.applied)
+	}
+	assertView(t, app, "1 providers", "moved to the trash")
+}
+
+func TestDeleteCurrentClearsSettings(t *testing.T) {
+	app, s := runScript(t, "d y")
+
+	if app.Config.CurrentProvider != "" {
+		t.Errorf("current = %q, want none", app.Config.CurrentProvider)
+	}
+	if len(s.applied) != 1 || s.applied[0] != "a->" {
+		t.Errorf("applied = %v, want [a->]", s.applied)
+	}
+	assertView(t, app, "removed from settings.json")
+}
+
+func TestDeleteSaveFailureKeepsSettings(t *testing.T) {
+	app, s := runScript(t, "d y", func(a *App, s *stubs) {
+		a.Save = func(cfg *config.Config) error { return errors.New("disk full") }
+	})
+
+	// settings.json still matches the config on disk
+	if len(s.applied) != 0 {
+		t.Errorf("applied = %v, want none", s.applied)
+	}
+	assertView(t, app, "Failed to save: disk full")
+}
+
+func TestDeleteSettingsFailureAfterSave(t *testing.T) {
+	app, s := runScript(t, "d y", func(a *App, s *stubs) {
+		a.Apply = func(cfg *config.Config, applied, p *config.Provider) ([]string, error) {
+			if s.saves != 1 {
+				t.Errorf("settings.json updated before the config was saved")
+			}
+			return nil, errors.New("read-only")
+		}
+	})
+
+	if len(app.Config.Trash) != 1 || s.saves != 1 {
+		t.Errorf("trash = %d, saves = %d, want the delete saved", len(app.Config.Trash), s.saves)
+	}
+	assertView(t, app, "moved to the trash, but failed to update Claude settings: read-only")
+}
+
+func TestDeleteCancelled(t *testing.T) {
+	app, s := runScript(t, "d n")
+
+	if len(app.Config.Providers) != 2 || s.saves != 0 {
+		t.Errorf("providers = %d, saves = %d", len(app.Config.Providers), s.saves)
+	}
+	assertView(t, app, "Delete cancelled")
+}
+
+func TestEnvView(t *testing.T) {
+	app, _ := runScript(t, "v")
+
+	assertView(t, app, "settings.json env managed by ccs", "ANTHROPIC_BASE_URL=https://a.example.com")
+	if view := viewText(app); strings.Contains(view, "sk-aaaaaaaaaaaa") {
+		t.Errorf("token not masked:\n%s", view)
+	}
+}
+
+func TestTest(t *testing.T) {
+	app, _ := runScript(t, "t")
+
+	assertView(t, app, "'a' OK in 42ms")
+}
+
+func TestQuit(t *testing.T) {
+	app, _ := runScript(t, "q down")
+
+	if !app.Quit() {
+		t.Error("not quit")
+	}
+}
@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// field is one editable provider field of the form
+type field struct {
+	label  string
+	get    func(p *config.Provider) string
+	set    func(p *config.Provider, value string) error
+	secret bool // Masked, and edited from an empty input that keeps the value
+	toggle bool // Enter cycles the value instead of opening an input
+}
+
+// setter returns a field setter using Provider.Set with a config.json name
+func setter(name string) func(p *config.Provider, value string) error {
+	return func(p *config.Provider, value string) error {
+		return p.Set(name, value)
+	}
+}
+
+// fields are the provider fields the form edits
+var fields = []field{
+	{label: "Name", get: func(p *config.Provider) string { return p.Name }, set: setter("name")},
+	{label: "Alias", get: func(p *config.Provider) string { return p.Alias }, set: setter("alias")},
+	{label: "Base URL", get: func(p *config.Provider) string { return p.BaseURL }, set: setter("base_url")},
+	{label: "API key", get: func(p *config.Provider) string { return p.APIKey },
+		set: func(p *config.Provider, v string) error {
+			if v == "" {
+				return nil
+			}
+			return p.Set("api_key", v)
+		}, secret: true},
+	{label: "API format", get: func(p *config.Provider) string {
+		if p.APIFormat == "" {
+			return config.FormatAnthropic
+		}
+		return p.APIFormat
+	}, set: func(p *config.Provider, v string) error {
+		if p.IsOpenAI() {
+			return p.Set("api_format", config.FormatAnthropic)
+		}
+		return p.Set("api_format", config.FormatOpenAI)
+	}, toggle: true},
+	{label: "Model", get: func(p *config.Provider) string { return p.Model }, set: setter("model")},
+	{label: "Small model", get: func(p *config.Provider) string { return p.SmallModel }, set: setter("small_model")},
+	{label: "Sonnet model", get: func(p *config.Provider) string { return p.SonnetModel }, set: setter("sonnet_model")},
+	{label: "Opus model", get: func(p *config.Provider) string { return p.OpusModel }, set: setter("opus_model")},
+	{label: "Haiku model", get: func(p *config.Provider) string { return p.HaikuModel }, set: setter("haiku_model")},
+	{label: "Timeout ms", get: func(p *config.Provider) string { return strconv.Itoa(p.Timeout) }, set: setter("timeout_ms")},
+	{label: "Tags", get: func(p *config.Provider) string { return strings.Join(p.Tags, ", ") }, set: setter("tags")},
+}
+
+// form edits one provider. original is the alias being edited, empty for
+// a provider being added or cloned
+type form struct {
+	title    string
+	provider config.Provider
+	original string
+
+	cursor  int
+	editing bool
+	input   []rune
+}
+
+// newForm starts editing a copy of p
+func newForm(title string, p config.Provider, original string) *form {
+	return &form{title: title, provider: p.Clone(), original: original}
+}
+
+// validate checks the fields a provider cannot do without
+func (f *form) validate() error {
+	p := &f.provider
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	// Existing aliases predating the rules can be kept
+	if p.Alias != f.original && config.ValidateAlias(p.Alias) != nil {
+		return fmt.Errorf("invalid alias %q, use letters, digits, '.', '_' and '-'", p.Alias)
+	}
+	return nil
+}
+
+// lines renders the form
+func (f *form) lines(showSecrets bool) []Line {
+	lines := []Line{{Text: " " + f.title, Style: StyleTitle}, {}}
+	for i, fd := range fields {
+		value := fd.get(&f.provider)
+		if fd.secret && !showSecrets {
+			value = config.MaskSecret(value)
+		}
+		if f.editing && i == f.cursor {
+			value = string(f.input) + "_"
+		}
+
+		line := Line{Text: fmt.Sprintf("   %-13s %s", fd.label, value)}
+		if i == f.cursor {
+			line.Text = " >" + line.Text[2:]
+			line.Style = StyleSelected
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// help is the key help shown under the form
+func (f *form) help() string {
+	if f.editing {
+		if fields[f.cursor].secret {
+			return "enter keep value (empty) or set  esc cancel  ctrl-u clear"
+		}
+		return "enter set  esc cancel  ctrl-u clear"
+	}
+	return "enter edit field  up/down move  s save  esc discard"
+}
+
+// startEdit opens the input for the field under the cursor, or cycles a
+// toggle field
+func (f *form) startEdit() {
+	fd := fields[f.cursor]
+	if fd.toggle {
+		fd.set(&f.provider, "")
+		return
+	}
+	f.editing = true
+	f.input = nil
+	if !fd.secret {
+		f.input = []rune(fd.get(&f.provider))
+	}
+}
+
+// handleInput handles a key while a field input is open. It returns an
+// error if the entered value was rejected, leaving the input open
+func (f *form) handleInput(k Key) error {
+	switch k.Code {
+	case KeyEnter:
+		value := strings.TrimSpace(string(f.input))
+		if err := fields[f.cursor].set(&f.provider, value); err != nil {
+			return err
+		}
+		f.editing = false
+	case KeyEsc:
+		f.editing = false
+	case KeyBackspace:
+		if len(f.input) > 0 {
+			f.input = f.input[:len(f.input)-1]
+		}
+	case KeyCtrlU:
+		f.input = nil
+	case KeyRune:
+		f.input = append(f.input, k.Rune)
+	}
+	return nil
+}
+
+// move moves the cursor, wrapping around
+func (f *form) move(delta int) {
+	f.cursor = (f.cursor + delta + len(fields)) % len(fields)
+}
@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyCode identifies a key press. Printable characters are KeyRune
+type KeyCode int
+
+const (
+	KeyRune KeyCode = iota
+	KeyEnter
+	KeyEsc
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDown
+	KeyTab
+	KeyBackspace
+	KeyDelete
+	KeyCtrlC
+	KeyCtrlU
+)
+
+// Key is one key press
+type Key struct {
+	Code KeyCode
+	Rune rune // Set for KeyRune
+}
+
+// Input delivers key presses. ReadKey returns io.EOF when input ends
+type Input interface {
+	ReadKey() (Key, error)
+}
+
+// keyNames are the key names understood by NewScript
+var keyNames = map[string]KeyCode{
+	"enter":     KeyEnter,
+	"esc":       KeyEsc,
+	"up":        KeyUp,
+	"down":      KeyDown,
+	"left":      KeyLeft,
+	"right":     KeyRight,
+	"home":      KeyHome,
+	"end":       KeyEnd,
+	"pgup":      KeyPgUp,
+	"pgdown":    KeyPgDown,
+	"tab":       KeyTab,
+	"backspace": KeyBackspace,
+	"delete":    KeyDelete,
+	"ctrl-c":    KeyCtrlC,
+	"ctrl-u":    KeyCtrlU,
+}
+
+// scriptInput replays a fixed list of keys
+type scriptInput struct {
+	keys []Key
+}
+
+// NewScript returns an Input that replays a script of space separated
+// tokens, for driving the UI from tests and scripts. A token is a key name
+// (enter, esc, up, down, left, right, home, end, pgup, pgdown, tab,
+// backspace, delete, ctrl-c, ctrl-u), "space", or text typed as-is. A
+// leading backslash types a token that would otherwise be a key name, e.g.
+// "\up"
+func NewScript(script string) (Input, error) {
+	in := &scriptInput{}
+	for _, token := range strings.Fields(script) {
+		if token == "space" {
+			in.keys = append(in.keys, Key{Code: KeyRune, Rune: ' '})
+			continue
+		}
+		if code, ok := keyNames[token]; ok {
+			in.keys = append(in.keys, Key{Code: code})
+			continue
+		}
+		token = strings.TrimPrefix(token, `\`)
+		if token == "" {
+			return nil, fmt.Errorf("empty token in key script")
+		}
+		for _, r := range token {
+			in.keys = append(in.keys, Key{Code: KeyRune, Rune: r})
+		}
+	}
+	return in, nil
+}
+
+func (in *scriptInput) ReadKey() (Key, error) {
+	if len(in.keys) == 0 {
+		return Key{}, io.EOF
+	}
+	k := in.keys[0]
+	in.keys = in.keys[1:]
+	return k, nil
+}
+
+// terminalInput decodes key presses from a terminal in raw mode
+type terminalInput struct {
+	r       io.Reader
+	pending []Key
+}
+
+// NewTerminalInput returns an Input decoding the bytes a terminal in raw
+// mode sends, including ANSI escape sequences for the arrow and editing keys
+func NewTerminalInput(r io.Reader) Input {
+	return &terminalInput{r: r}
+}
+
+func (in *terminalInput) ReadKey() (Key, error) {
+	buf := make([]byte, 256)
+	for len(in.pending) == 0 {
+		n, err := in.r.Read(buf)
+		if n > 0 {
+			// An escape sequence arrives in a single read, so a lone ESC
+			// at the end of a read is the escape key itself
+			in.pending = decodeKeys(buf[:n])
+		}
+		if err != nil && len(in.pending) == 0 {
+			return Key{}, err
+		}
+	}
+	k := in.pending[0]
+	in.pending = in.pending[1:]
+	return k, nil
+}
+
+// decodeKeys decodes one read of terminal input
+func decodeKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			k, n := decodeEscape(b)
+			keys = append(keys, k)
+			b = b[n:]
+			continue
+		case c == '\r' || c == '\n':
+			keys = append(keys, Key{Code: KeyEnter})
+		case c == '\t':
+			keys = append(keys, Key{Code: KeyTab})
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, Key{Code: KeyBackspace})
+		case c == 0x03:
+			keys = append(keys, Key{Code: KeyCtrlC})
+		case c == 0x15:
+			keys = append(keys, Key{Code: KeyCtrlU})
+		case c < 0x20:
+			// Other control characters are ignored
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, Key{Code: KeyRune, Rune: r})
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// decodeEscape decodes an escape sequence at the start of b, returning the
+// key and the number of bytes used. Unknown sequences decode as KeyEsc
+func decodeEscape(b []byte) (Key, int) {
+	if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+		return Key{Code: KeyEsc}, 1
+	}
+
+	// CSI: parameters, then a final byte in 0x40-0x7e
+	i := 2
+	for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+		i++
+	}
+	if i == len(b) {
+		return Key{Code: KeyEsc}, len(b)
+	}
+	params := string(b[2:i])
+	n := i + 1
+
+	switch b[i] {
+	case 'A':
+		return Key{Code: KeyUp}, n
+	case 'B':
+		return Key{Code: KeyDown}, n
+	case 'C':
+		return Key{Code: KeyRight}, n
+	case 'D':
+		return Key{Code: KeyLeft}, n
+	case 'H':
+		return Key{Code: KeyHome}, n
+	case 'F':
+		return Key{Code: KeyEnd}, n
+	case '~':
+		switch params {
+		case "1", "7":
+			return Key{Code: KeyHome}, n
+		case "4", "8":
+			return Key{Code: KeyEnd}, n
+		case "3":
+			return Key{Code: KeyDelete}, n
+		case "5":
+			return Key{Code: KeyPgUp}, n
+		case "6":
+			return Key{Code: KeyPgDown}, n
+		}
+	}
+	return Key{Code: KeyEsc}, n
+}
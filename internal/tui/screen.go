@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"io"
+	"strings"
+
+	"github.com/katz/ccs/internal/display"
+)
+
+// Style is how a screen line is drawn
+type Style int
+
+const (
+	StyleNormal Style = iota
+	StyleTitle
+	StyleSelected
+	StyleCurrent
+	StyleDim
+	StyleError
+	StyleOK
+)
+
+// styleCodes are the ANSI attributes of each style
+var styleCodes = map[Style]string{
+	StyleTitle:    "\033[1;7m",
+	StyleSelected: "\033[7m",
+	StyleCurrent:  "\033[32m",
+	StyleDim:      "\033[2m",
+	StyleError:    "\033[31m",
+	StyleOK:       "\033[32m",
+}
+
+// Line is one row of the screen. Text is plain, so frames can be inspected
+// without parsing ANSI codes
+type Line struct {
+	Text  string
+	Style Style
+}
+
+// ANSI sequences for the full-screen session
+const (
+	enterScreen = "\033[?1049h\033[?25l" // Alternate screen, hidden cursor
+	leaveScreen = "\033[?25h\033[?1049l"
+)
+
+// render draws a frame over the previous one, fitting each line to width.
+// Lines end in \r\n since a terminal in raw mode does not translate \n
+func render(w io.Writer, lines []Line, width int) error {
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for i, line := range lines {
+		text := fit(line.Text, width)
+		if code, ok := styleCodes[line.Style]; ok {
+			if line.Style == StyleSelected || line.Style == StyleTitle {
+				// Pad so the highlight spans the row
+				text = pad(text, width)
+			}
+			text = code + text + "\033[0m"
+		}
+		b.WriteString(text)
+		b.WriteString("\033[K")
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\033[J")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fit truncates s to at most width terminal columns, marking the cut with "~"
+func fit(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return display.Truncate(s, width, "~")
+}
+
+// pad right-pads s with spaces to width terminal columns
+func pad(s string, width int) string {
+	return display.Pad(s, width)
+}
@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// Budget periods and kinds
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+	KindTokens    = "tokens"
+	KindCost      = "cost"
+)
+
+// BudgetStatus is the consumption against one budget limit
+type BudgetStatus struct {
+	Period   string  `json:"period"` // daily or monthly
+	Kind     string  `json:"kind"`   // tokens or cost
+	Limit    float64 `json:"limit"`
+	Used     float64 `json:"used"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// Remaining returns what is left of the limit, never below zero
+func (bs BudgetStatus) Remaining() float64 {
+	if bs.Used >= bs.Limit {
+		return 0
+	}
+	return bs.Limit - bs.Used
+}
+
+// BudgetStart returns the earliest time any budget period covers at now,
+// which is the start of the current month
+func BudgetStart(now time.Time) time.Time {
+	y, m, _ := now.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+}
+
+// CheckBudget compares a provider's records with its budget. records may
+// include other providers and older entries; only the provider's records in
+// the current day and month count
+func CheckBudget(alias string, b *config.Budget, records []Record, prices map[string]config.Price, now time.Time) []BudgetStatus {
+	if b == nil {
+		return nil
+	}
+
+	y, m, d := now.Date()
+	dayStart := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	monthStart := BudgetStart(now)
+
+	var day, month Summary
+	for _, rec := range records {
+		if rec.Provider != alias || rec.Time.Before(monthStart) {
+			continue
+		}
+		month.Add(rec, prices)
+		if !rec.Time.Before(dayStart) {
+			day.Add(rec, prices)
+		}
+	}
+
+	var statuses []BudgetStatus
+	add := func(period, kind string, limit, used float64) {
+		if limit > 0 {
+			statuses = append(statuses, BudgetStatus{
+				Period:   period,
+				Kind:     kind,
+				Limit:    limit,
+				Used:     used,
+				Exceeded: used >= limit,
+			})
+		}
+	}
+	add(PeriodDaily, KindTokens, float64(b.DailyTokens), float64(day.TotalTokens()))
+	add(PeriodMonthly, KindTokens, float64(b.MonthlyTokens), float64(month.TotalTokens()))
+	add(PeriodDaily, KindCost, b.DailyCost, day.Cost)
+	add(PeriodMonthly, KindCost, b.MonthlyCost, month.Cost)
+	return statuses
+}
+
+// AnyExceeded reports whether any budget limit is exceeded
+func AnyExceeded(statuses []BudgetStatus) bool {
+	for _, bs := range statuses {
+		if bs.Exceeded {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+func TestCheckBudget(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.Local)
+	today := now.Add(-time.Hour)
+	thisMonth := time.Date(2026, 10, 2, 9, 0, 0, 0, time.Local)
+	lastMonth := time.Date(2026, 9, 30, 23, 0, 0, 0, time.Local)
+	records := []Record{
+		{Time: today, Provider: "ds", Model: "deepseek-chat", InputTokens: 600000, OutputTokens: 400000},
+		{Time: thisMonth, Provider: "ds", Model: "deepseek-chat", InputTokens: 1000000},
+		{Time: lastMonth, Provider: "ds", Model: "deepseek-chat", InputTokens: 5000000},
+		{Time: today, Provider: "or", Model: "deepseek-chat", InputTokens: 9000000},
+	}
+	prices := map[string]config.Price{"deepseek-chat": {Input: 1, Output: 2}}
+
+	tests := []struct {
+		name   string
+		budget *config.Budget
+		want   []BudgetStatus
+	}{
+		{"no budget", nil, nil},
+		{"no limits", &config.Budget{Hard: true}, nil},
+		{"within", &config.Budget{DailyTokens: 2000000, MonthlyCost: 10}, []BudgetStatus{
+			{Period: PeriodDaily, Kind: KindTokens, Limit: 2000000, Used: 1000000},
+			{Period: PeriodMonthly, Kind: KindCost, Limit: 10, Used: 2.4},
+		}},
+		{"exceeded", &config.Budget{DailyTokens: 1000000, MonthlyTokens: 3000000, DailyCost: 1}, []BudgetStatus{
+			{Period: PeriodDaily, Kind: KindTokens, Limit: 1000000, Used: 1000000, Exceeded: true},
+			{Period: PeriodMonthly, Kind: KindTokens, Limit: 3000000, Used: 2000000},
+			{Period: PeriodDaily, Kind: KindCost, Limit: 1, Used: 1.4, Exceeded: true},
+		}},
+	}
+
+	for _, tt := range tests {
+		got := CheckBudget("ds", tt.budget, records, prices, now)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+		if AnyExceeded(got) != (tt.name == "exceeded") {
+			t.Errorf("%s: AnyExceeded = %v", tt.name, AnyExceeded(got))
+		}
+	}
+}
+
+func TestBudgetStatusRemaining(t *testing.T) {
+	tests := []struct {
+		limit, used, want float64
+	}{
+		{100, 30, 70},
+		{100, 100, 0},
+		{100, 150, 0},
+	}
+	for _, tt := range tests {
+		if got := (BudgetStatus{Limit: tt.limit, Used: tt.used}).Remaining(); got != tt.want {
+			t.Errorf("Remaining(%v of %v) = %v, want %v", tt.used, tt.limit, got, tt.want)
+		}
+	}
+}
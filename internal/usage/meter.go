@@ -0,0 +1,135 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// maxBufferedBody bounds how much of a non-streaming response is kept for parsing
+const maxBufferedBody = 8 << 20
+
+// usageFields is the usage object of a Messages API response or event
+type usageFields struct {
+	InputTokens              *int64 `json:"input_tokens"`
+	OutputTokens             *int64 `json:"output_tokens"`
+	CacheCreationInputTokens *int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     *int64 `json:"cache_read_input_tokens"`
+}
+
+// Meter wraps a Messages API response body and extracts token usage while
+// the body is read, from a JSON response or from a server-sent event
+// stream (message_start and message_delta events)
+type Meter struct {
+	io.ReadCloser
+
+	stream  bool
+	buf     bytes.Buffer // Whole JSON body, or the unterminated SSE line
+	model   string
+	usage   Record
+	matched bool
+}
+
+// NewMeter wraps body. stream selects SSE parsing
+func NewMeter(body io.ReadCloser, stream bool) *Meter {
+	return &Meter{ReadCloser: body, stream: stream}
+}
+
+// Read reads from the body, parsing usage from the data seen so far
+func (m *Meter) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	if n > 0 {
+		if m.stream {
+			m.scanLines(p[:n])
+		} else if m.buf.Len() < maxBufferedBody {
+			m.buf.Write(p[:n])
+		}
+	}
+	return n, err
+}
+
+// Result returns the usage seen in the body. ok is false if the body
+// carried no usage, e.g. for an error response
+func (m *Meter) Result() (rec Record, ok bool) {
+	if !m.stream && m.buf.Len() > 0 {
+		var resp struct {
+			Model string       `json:"model"`
+			Usage *usageFields `json:"usage"`
+		}
+		if json.Unmarshal(m.buf.Bytes(), &resp) == nil && resp.Usage != nil {
+			m.model = resp.Model
+			m.apply(resp.Usage)
+		}
+		m.buf.Reset()
+	}
+
+	rec = m.usage
+	rec.Model = m.model
+	return rec, m.matched
+}
+
+// scanLines feeds complete SSE lines to parseEvent
+func (m *Meter) scanLines(data []byte) {
+	m.buf.Write(data)
+	for {
+		line, err := m.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line for the next read
+			m.buf.Reset()
+			m.buf.WriteString(line)
+			return
+		}
+		m.parseEvent(strings.TrimSpace(line))
+	}
+}
+
+// parseEvent parses one SSE "data:" line
+func (m *Meter) parseEvent(line string) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return
+	}
+
+	var event struct {
+		Type    string `json:"type"`
+		Message struct {
+			Model string       `json:"model"`
+			Usage *usageFields `json:"usage"`
+		} `json:"message"`
+		Usage *usageFields `json:"usage"`
+	}
+	if json.Unmarshal([]byte(strings.TrimSpace(data)), &event) != nil {
+		return
+	}
+
+	switch event.Type {
+	case "message_start":
+		m.model = event.Message.Model
+		if event.Message.Usage != nil {
+			m.apply(event.Message.Usage)
+		}
+	case "message_delta":
+		// Delta usage is cumulative, so it replaces earlier counts
+		if event.Usage != nil {
+			m.apply(event.Usage)
+		}
+	}
+}
+
+// apply copies the usage fields that are present
+func (m *Meter) apply(u *usageFields) {
+	m.matched = true
+	if u.InputTokens != nil {
+		m.usage.InputTokens = *u.InputTokens
+	}
+	if u.OutputTokens != nil {
+		m.usage.OutputTokens = *u.OutputTokens
+	}
+	if u.CacheCreationInputTokens != nil {
+		m.usage.CacheCreationInputTokens = *u.CacheCreationInputTokens
+	}
+	if u.CacheReadInputTokens != nil {
+		m.usage.CacheReadInputTokens = *u.CacheReadInputTokens
+	}
+}
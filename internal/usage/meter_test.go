@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMeter(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		body   string
+		want   Record
+		ok     bool
+	}{
+		{
+			name:   "stream",
+			stream: true,
+			body: "event: message_start\n" +
+				`data: {"type":"message_start","message":{"model":"claude-sonnet-4","usage":{"input_tokens":100,"output_tokens":1,"cache_read_input_tokens":50}}}` + "\n\n" +
+				"event: content_block_delta\n" +
+				`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}` + "\n\n" +
+				"event: message_delta\n" +
+				`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":20}}` + "\n\n" +
+				"event: message_delta\n" +
+				`data: {"type":"message_delta","delta":{},"usage":{"output_tokens":42}}` + "\n\n" +
+				"event: message_stop\n" +
+				`data: {"type":"message_stop"}` + "\n\n",
+			want: Record{Model: "claude-sonnet-4", InputTokens: 100, OutputTokens: 42, CacheReadInputTokens: 50},
+			ok:   true,
+		},
+		{
+			name:   "message_delta with input tokens",
+			stream: true,
+			body: `data: {"type":"message_start","message":{"model":"glm-4.6","usage":{"input_tokens":0,"output_tokens":0}}}` + "\n" +
+				`data: {"type":"message_delta","usage":{"input_tokens":300,"output_tokens":12,"cache_creation_input_tokens":7}}` + "\n",
+			want: Record{Model: "glm-4.6", InputTokens: 300, OutputTokens: 12, CacheCreationInputTokens: 7},
+			ok:   true,
+		},
+		{
+			name:   "last line without newline",
+			stream: true,
+			body:   `data: {"type":"message_delta","usage":{"output_tokens":5}}`,
+			want:   Record{},
+			ok:     false,
+		},
+		{
+			name:   "error event",
+			stream: true,
+			body:   "event: error\n" + `data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}` + "\n\n",
+			ok:     false,
+		},
+		{
+			name:   "malformed data",
+			stream: true,
+			body:   "data: {not json\n: ping\n\n",
+			ok:     false,
+		},
+		{
+			name: "json response",
+			body: `{"type":"message","model":"deepseek-chat","content":[],"usage":{"input_tokens":8,"output_tokens":3}}`,
+			want: Record{Model: "deepseek-chat", InputTokens: 8, OutputTokens: 3},
+			ok:   true,
+		},
+		{
+			name: "json error",
+			body: `{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`,
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One byte per read, so events are split across reads
+			m := NewMeter(io.NopCloser(iotest.OneByteReader(strings.NewReader(tt.body))), tt.stream)
+			if _, err := io.ReadAll(m); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := m.Result()
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Result() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// Grouping keys for Summarize
+const (
+	ByProvider = "provider"
+	ByModel    = "model"
+	ByDay      = "day"
+)
+
+// Summary aggregates the usage of one group
+type Summary struct {
+	Key          string  `json:"key"`
+	Requests     int     `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CacheWrite   int64   `json:"cache_creation_input_tokens"`
+	CacheRead    int64   `json:"cache_read_input_tokens"`
+	Cost         float64 `json:"cost"`     // Estimated USD cost of the priced records
+	Unpriced     int     `json:"unpriced"` // Records whose model has no price
+}
+
+// Summarize groups records by provider, model or day, sorted by key
+func Summarize(records []Record, by string, prices map[string]config.Price) ([]Summary, error) {
+	groups := make(map[string]*Summary)
+	for _, rec := range records {
+		var key string
+		switch by {
+		case ByProvider:
+			key = rec.Provider
+		case ByModel:
+			key = rec.Model
+		case ByDay:
+			key = rec.Time.Local().Format("2006-01-02")
+		default:
+			return nil, fmt.Errorf("unknown grouping %q, expected provider, model or day", by)
+		}
+
+		sum, ok := groups[key]
+		if !ok {
+			sum = &Summary{Key: key}
+			groups[key] = sum
+		}
+		sum.Add(rec, prices)
+	}
+
+	list := make([]Summary, 0, len(groups))
+	for _, sum := range groups {
+		list = append(list, *sum)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list, nil
+}
+
+// Add adds a record to the summary
+func (s *Summary) Add(rec Record, prices map[string]config.Price) {
+	s.Requests++
+	s.InputTokens += rec.InputTokens
+	s.OutputTokens += rec.OutputTokens
+	s.CacheWrite += rec.CacheCreationInputTokens
+	s.CacheRead += rec.CacheReadInputTokens
+
+	if cost, ok := Cost(rec, prices); ok {
+		s.Cost += cost
+	} else {
+		s.Unpriced++
+	}
+}
+
+// TotalTokens returns all tokens in the summary, including cache tokens
+func (s *Summary) TotalTokens() int64 {
+	return s.InputTokens + s.OutputTokens + s.CacheWrite + s.CacheRead
+}
+
+// Cost estimates the USD cost of a record. ok is false if no price matches
+func Cost(rec Record, prices map[string]config.Price) (float64, bool) {
+	price, ok := FindPrice(rec.Model, prices)
+	if !ok {
+		return 0, false
+	}
+
+	cost := float64(rec.InputTokens)*price.Input +
+		float64(rec.OutputTokens)*price.Output +
+		float64(rec.CacheCreationInputTokens)*price.CacheWrite +
+		float64(rec.CacheReadInputTokens)*price.CacheRead
+	return cost / 1e6, true
+}
+
+// FindPrice returns the price for a model: an exact key wins, otherwise the
+// longest matching glob key
+func FindPrice(model string, prices map[string]config.Price) (config.Price, bool) {
+	if price, ok := prices[model]; ok {
+		return price, true
+	}
+
+	best := ""
+	for pattern := range prices {
+		if config.GlobMatch(pattern, model) && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return config.Price{}, false
+	}
+	return prices[best], true
+}
+
+// ParseSince parses a relative period such as "24h", "7d" or "2w", or an
+// absolute date such as "2025-01-31", into a start time
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid period %q", value)
+			}
+			return now.Add(-time.Duration(count) * unit), nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid period %q, use e.g. 24h, 7d, 2w or 2025-01-31", value)
+	}
+	return now.Add(-d), nil
+}
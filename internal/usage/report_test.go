@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+func TestSummarize(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	records := []Record{
+		{Time: day1, Provider: "or", Model: "claude-sonnet-4", InputTokens: 1000000, OutputTokens: 100000},
+		{Time: day2, Provider: "ds", Model: "deepseek-chat", InputTokens: 2000000, OutputTokens: 1000000, CacheReadInputTokens: 1000000},
+		{Time: day2, Provider: "or", Model: "unknown-model", InputTokens: 10, OutputTokens: 5},
+	}
+	prices := map[string]config.Price{
+		"claude-sonnet-*": {Input: 3, Output: 15},
+		"deepseek-chat":   {Input: 0.28, Output: 0.42, CacheRead: 0.028},
+	}
+
+	tests := []struct {
+		by   string
+		want []Summary
+	}{
+		{ByProvider, []Summary{
+			{Key: "ds", Requests: 1, InputTokens: 2000000, OutputTokens: 1000000, CacheRead: 1000000, Cost: 0.56 + 0.42 + 0.028},
+			{Key: "or", Requests: 2, InputTokens: 1000010, OutputTokens: 100005, Cost: 3 + 1.5, Unpriced: 1},
+		}},
+		{ByModel, []Summary{
+			{Key: "claude-sonnet-4", Requests: 1, InputTokens: 1000000, OutputTokens: 100000, Cost: 4.5},
+			{Key: "deepseek-chat", Requests: 1, InputTokens: 2000000, OutputTokens: 1000000, CacheRead: 1000000, Cost: 1.008},
+			{Key: "unknown-model", Requests: 1, InputTokens: 10, OutputTokens: 5, Unpriced: 1},
+		}},
+		{ByDay, []Summary{
+			{Key: "2026-10-01", Requests: 1, InputTokens: 1000000, OutputTokens: 100000, Cost: 4.5},
+			{Key: "2026-10-02", Requests: 2, InputTokens: 2000010, OutputTokens: 1000005, CacheRead: 1000000, Cost: 1.008, Unpriced: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		got, err := Summarize(records, tt.by, prices)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.by, err)
+		}
+		// Costs are compared separately with a tolerance
+		for i := range got {
+			if i < len(tt.want) && math.Abs(got[i].Cost-tt.want[i].Cost) < 1e-9 {
+				got[i].Cost = tt.want[i].Cost
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.by, got, tt.want)
+		}
+	}
+
+	if _, err := Summarize(records, "week", prices); err == nil {
+		t.Error("unknown grouping accepted")
+	}
+	if got, err := Summarize(nil, ByProvider, nil); err != nil || len(got) != 0 {
+		t.Errorf("no records: %v, %v", got, err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		value string
+		want  time.Time
+		err   bool
+	}{
+		{"24h", now.Add(-24 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"7d", now.Add(-7 * 24 * time.Hour), false},
+		{"2w", now.Add(-14 * 24 * time.Hour), false},
+		{"0d", now, false},
+		{"2025-01-31", time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local), false},
+		{"", time.Time{}, true},
+		{"d", time.Time{}, true},
+		{"1.5d", time.Time{}, true},
+		{"week", time.Time{}, true},
+		{"2025-13-01", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.value, now)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseSince(%q) error = %v, want error %v", tt.value, err, tt.err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
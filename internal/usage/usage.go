@@ -0,0 +1,149 @@
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/katz/ccs/internal/config"
+)
+
+// Record is the token usage of one Messages API response
+type Record struct {
+	Time                     time.Time `json:"time"`
+	Provider                 string    `json:"provider"` // Provider alias
+	Model                    string    `json:"model"`
+	InputTokens              int64     `json:"input_tokens"`
+	OutputTokens             int64     `json:"output_tokens"`
+	CacheCreationInputTokens int64     `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int64     `json:"cache_read_input_tokens,omitempty"`
+}
+
+// TotalTokens returns all tokens of the record, including cache tokens
+func (r Record) TotalTokens() int64 {
+	return r.InputTokens + r.OutputTokens + r.CacheCreationInputTokens + r.CacheReadInputTokens
+}
+
+// Store is an append-only JSONL file of usage records
+type Store struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// GetUsagePath returns the path of the usage store
+func GetUsagePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.jsonl"), nil
+}
+
+// Open returns the default usage store
+func Open() (*Store, error) {
+	path, err := GetUsagePath()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Path: path}, nil
+}
+
+// Append adds a record to the store
+func (s *Store) Append(rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load returns the records at or after since. Malformed lines, such as a
+// line cut short by a crash, are skipped
+func (s *Store) Load(since time.Time) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Time.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Rename points the records of a renamed provider at its new alias, so its
+// budget and usage history carry over. Records a running proxy appends
+// while the file is rewritten may be lost
+func (s *Store) Rename(oldAlias, newAlias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	changed := false
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for i, line := range lines {
+		var rec Record
+		if json.Unmarshal(line, &rec) != nil || rec.Provider != oldAlias {
+			continue
+		}
+		rec.Provider = newAlias
+		out, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		lines[i] = append(out, '\n')
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, bytes.Join(lines, nil), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
@@ -0,0 +1,61 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreRename(t *testing.T) {
+	store := &Store{Path: filepath.Join(t.TempDir(), "usage.jsonl")}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, rec := range []Record{
+		{Time: now, Provider: "old", Model: "m", InputTokens: 10},
+		{Time: now, Provider: "other", Model: "m", InputTokens: 20},
+		{Time: now, Provider: "old", Model: "m", OutputTokens: 30},
+	} {
+		if err := store.Append(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A line cut short by a crash is kept as it is
+	f, _ := os.OpenFile(store.Path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"time":"2026-10-01T12:00:00Z","provider":"old"` + "\n")
+	f.Close()
+
+	if err := store.Rename("old", "new"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	records, err := store.Load(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var providers []string
+	for _, rec := range records {
+		providers = append(providers, rec.Provider)
+	}
+	if got, want := strings.Join(providers, ","), "new,other,new"; got != want {
+		t.Errorf("providers = %s, want %s", got, want)
+	}
+	if records[2].OutputTokens != 30 {
+		t.Errorf("record changed: %+v", records[2])
+	}
+
+	data, _ := os.ReadFile(store.Path)
+	if !strings.HasSuffix(string(data), `"provider":"old"`+"\n") {
+		t.Errorf("malformed line not kept:\n%s", data)
+	}
+}
+
+func TestStoreRenameMissingFile(t *testing.T) {
+	store := &Store{Path: filepath.Join(t.TempDir(), "usage.jsonl")}
+	if err := store.Rename("old", "new"); err != nil {
+		t.Errorf("Rename: %v", err)
+	}
+	if _, err := os.Stat(store.Path); !os.IsNotExist(err) {
+		t.Errorf("store created: %v", err)
+	}
+}